@@ -0,0 +1,88 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+	"os"
+)
+
+const redacted = "******"
+
+// configCmd represents the config command
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "inspect the effective configuration",
+}
+
+var configValidateCmd = &cobra.Command{
+	Use:   "validate",
+	Short: "check the effective config against the schema",
+	Run: func(cmd *cobra.Command, args []string) {
+		if _, errs := loadSettings(config.Load()); len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Println(err)
+			}
+			os.Exit(1)
+		}
+
+		fmt.Printf("%s is valid\n", configFile)
+	},
+}
+
+var configPrintCmd = &cobra.Command{
+	Use:   "print",
+	Short: "print the merged config with secrets redacted",
+	Run: func(cmd *cobra.Command, args []string) {
+		out, err := yaml.Marshal(redact(config.Load().AllSettings(), ""))
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+
+		fmt.Print(string(out))
+	},
+}
+
+func init() {
+	configCmd.AddCommand(configValidateCmd)
+	configCmd.AddCommand(configPrintCmd)
+}
+
+func redact(settings map[string]interface{}, prefix string) map[string]interface{} {
+	result := make(map[string]interface{}, len(settings))
+	for k, v := range settings {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		result[k] = redactValue(v, key)
+	}
+
+	return result
+}
+
+// redactValue redact the secrets of a config value, maps and list elements included, e.g. mysql.replicas
+func redactValue(v interface{}, key string) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		return redact(vv, key)
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(vv))
+		for k, value := range vv {
+			converted[fmt.Sprint(k)] = value
+		}
+		return redact(converted, key)
+	case []interface{}:
+		result := make([]interface{}, len(vv))
+		for i, item := range vv {
+			result[i] = redactValue(item, key)
+		}
+		return result
+	}
+
+	if isSecretKey(key) && v != "" && v != nil {
+		return redacted
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/spf13/cobra"
+	"os"
+	"strconv"
+	"top-ping/pkg/database"
+	"top-ping/pkg/logger"
+	"top-ping/pkg/migrate"
+)
+
+// migrateCmd represents the migrate command
+var migrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "apply or revert the schema migrations of the mysql section",
+}
+
+var migrateUpCmd = &cobra.Command{
+	Use:   "up",
+	Short: "apply all pending migrations",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator := newMigrator()
+		applied, err := migrator.Up(cmd.Context())
+		for _, m := range applied {
+			fmt.Printf("applied  %d_%s\n", m.Version, m.Name)
+		}
+		exitOnError(err)
+		if len(applied) == 0 {
+			fmt.Println("no pending migrations")
+		}
+	},
+}
+
+var migrateDownCmd = &cobra.Command{
+	Use:   "down N",
+	Short: "revert the last N applied migrations",
+	Args:  cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		n, err := strconv.Atoi(args[0])
+		if err != nil || n <= 0 {
+			exitOnError(fmt.Errorf("N must be a positive number, got %q", args[0]))
+		}
+
+		migrator := newMigrator()
+		reverted, err := migrator.Down(cmd.Context(), n)
+		for _, m := range reverted {
+			fmt.Printf("reverted %d_%s\n", m.Version, m.Name)
+		}
+		exitOnError(err)
+	},
+}
+
+var migrateStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "list the migrations and whether they are applied",
+	Run: func(cmd *cobra.Command, args []string) {
+		migrator := newMigrator()
+		statuses, err := migrator.Status(cmd.Context())
+		exitOnError(err)
+
+		for _, s := range statuses {
+			appliedAt := "pending"
+			if s.AppliedAt != nil {
+				appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-20s %d_%s\n", appliedAt, s.Version, s.Name)
+		}
+	},
+}
+
+func init() {
+	migrateCmd.AddCommand(migrateUpCmd)
+	migrateCmd.AddCommand(migrateDownCmd)
+	migrateCmd.AddCommand(migrateStatusCmd)
+}
+
+// newMigrator connect with the same settings as the server
+func newMigrator() *migrate.Migrator {
+	s, errs := loadSettings(config.Load())
+	if len(errs) > 0 {
+		for _, err := range errs {
+			fmt.Println(err)
+		}
+		os.Exit(1)
+	}
+
+	logger.Init(s.Application.Profile, &s.Logging)
+	exitOnError(database.Init(context.Background(), &s.Mysql))
+
+	migrator, err := migrate.New(database.Get(), s.Mysql.Driver())
+	exitOnError(err)
+	return migrator
+}
+
+func exitOnError(err error) {
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,255 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"top-ping/pkg/logger"
+	"top-ping/pkg/ratelimit"
+)
+
+// reloadableKeys config keys applied without a restart, the others only take effect on the next start
+var reloadableKeys = []string{
+	"logging.level",
+	"logging.skippaths",
+	"logging.skipfields",
+	"logging.desensitize",
+	"logging.redactheaders",
+	"logging.body",
+	"logging.statussampling",
+	"ratelimit",
+}
+
+var secretKeys = []string{"password", "secret", "token"}
+
+var reloadMu sync.Mutex
+
+// reloadDelay editors write a file in several events, they are reloaded once
+const reloadDelay = 100 * time.Millisecond
+
+// watchConfig reload the config when the file or its profile overlay changes, or on SIGHUP
+func watchConfig(ctx context.Context, limiter *ratelimit.Limiter) {
+	err := watchFiles(ctx, configFiles, func() {
+		reloadConfig(limiter, "file change")
+	})
+	if err != nil {
+		logger.Errorf(ctx, "Config: watching %s failed: %v", configFile, err)
+	}
+
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(hup)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-hup:
+				reloadConfig(limiter, "SIGHUP")
+			}
+		}
+	}()
+}
+
+// configFiles the base config and the overlay of the applied profile, which may not exist
+func configFiles() []string {
+	files := []string{configFile}
+	if v := config.Load(); v != nil && v.GetString("application.profile") != "" {
+		files = append(files, overlayFile(configFile, v.GetString("application.profile")))
+	}
+	return files
+}
+
+// watchFiles call onChange when one of files is written or replaced, until ctx is done.
+// The directory is watched rather than the files, as editors and kubernetes config maps replace them by a rename
+// that a watch of the old file never sees. files is called on every event, the overlay follows the profile.
+// All files are in the same directory, an overlay is next to its base config.
+func watchFiles(ctx context.Context, files func() []string, onChange func()) error {
+	dir := filepath.Dir(files()[0])
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+		// symlinked files, e.g. config maps, change when their target is swapped
+		targets := realPaths(files())
+		var timer *time.Timer
+		defer func() {
+			if timer != nil {
+				timer.Stop()
+			}
+		}()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+
+				current := realPaths(files())
+				if !isOneOf(event.Name, files()) && reflect.DeepEqual(current, targets) {
+					continue
+				}
+				targets = current
+				if timer != nil {
+					timer.Stop()
+				}
+				timer = time.AfterFunc(reloadDelay, onChange)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.Errorf(ctx, "Config: watching %s: %v", dir, err)
+			}
+		}
+	}()
+	return nil
+}
+
+func realPaths(files []string) []string {
+	paths := make([]string, len(files))
+	for i, file := range files {
+		paths[i], _ = filepath.EvalSymlinks(file)
+	}
+	return paths
+}
+
+func isOneOf(name string, files []string) bool {
+	for _, file := range files {
+		if filepath.Clean(name) == filepath.Clean(file) {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadConfig load the file again, validate it and apply the reloadable parts, the running config is kept on error
+func reloadConfig(limiter *ratelimit.Limiter, trigger string) {
+	reloadMu.Lock()
+	defer reloadMu.Unlock()
+
+	ctx := context.Background()
+	v, err := loadConfig(configFile)
+	if err != nil {
+		logger.Errorf(ctx, "Config: reload on %s failed: %v", trigger, err)
+		return
+	}
+
+	s, errs := loadSettings(v)
+	if len(errs) > 0 {
+		for _, err := range errs {
+			logger.Errorf(ctx, "Config: reload on %s rejected: %v", trigger, err)
+		}
+		return
+	}
+
+	changes, changedKeys := diffConfig(config.Load(), v)
+	if len(changes) == 0 {
+		logger.Infof(ctx, "Config: reload on %s, nothing changed", trigger)
+		return
+	}
+
+	var restartKeys []string
+	for _, key := range changedKeys {
+		if !isReloadable(key) {
+			restartKeys = append(restartKeys, key)
+		}
+	}
+
+	if anyHasPrefix(changedKeys, "logging.") {
+		if err := logger.UpdateConfig(&s.Logging); err != nil {
+			logger.Errorf(ctx, "Config: reload on %s, logging not applied: %v", trigger, err)
+			return
+		}
+	}
+	if anyHasPrefix(changedKeys, "ratelimit.") {
+		limiter.Update(&s.RateLimit)
+	}
+
+	config.Store(v)
+	logger.Infof(ctx, "Config: reloaded on %s, changes: [%s]", trigger, strings.Join(changes, "; "))
+	if len(restartKeys) > 0 {
+		logger.Warnf(ctx, "Config: %s changed but only take effect after a restart", strings.Join(restartKeys, ", "))
+	}
+}
+
+// diffConfig changed keys between two configs, secret values are not printed, nested ones in lists neither
+func diffConfig(old, new *viper.Viper) (changes []string, keys []string) {
+	all := map[string]struct{}{}
+	for _, key := range old.AllKeys() {
+		all[key] = struct{}{}
+	}
+	for _, key := range new.AllKeys() {
+		all[key] = struct{}{}
+	}
+
+	for key := range all {
+		oldValue, newValue := old.Get(key), new.Get(key)
+		if reflect.DeepEqual(oldValue, newValue) {
+			continue
+		}
+
+		keys = append(keys, key)
+		oldShown, newShown := redactValue(oldValue, key), redactValue(newValue, key)
+		if isSecretKey(key) || reflect.DeepEqual(oldShown, newShown) {
+			// only secrets changed
+			changes = append(changes, fmt.Sprintf("%s: changed", key))
+		} else {
+			changes = append(changes, fmt.Sprintf("%s: %v -> %v", key, oldShown, newShown))
+		}
+	}
+
+	sort.Strings(keys)
+	sort.Strings(changes)
+	return changes, keys
+}
+
+func isSecretKey(key string) bool {
+	key = strings.ToLower(key)
+	for _, secret := range secretKeys {
+		if strings.Contains(key, secret) {
+			return true
+		}
+	}
+	return false
+}
+
+func isReloadable(key string) bool {
+	for _, reloadable := range reloadableKeys {
+		if key == reloadable || strings.HasPrefix(key, reloadable+".") {
+			return true
+		}
+	}
+	return false
+}
+
+func anyHasPrefix(keys []string, prefix string) bool {
+	for _, key := range keys {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
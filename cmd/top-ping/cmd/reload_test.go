@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestWatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	// a config map: application.yml -> ..data/application.yml, ..data -> v1
+	for _, version := range []string{"v1", "v2"} {
+		if err := os.Mkdir(filepath.Join(dir, version), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		writeFile(t, filepath.Join(dir, version, "application.yml"), "application:\n  profile: dev\n")
+	}
+	symlink(t, "v1", filepath.Join(dir, "..data"))
+	symlink(t, filepath.Join("..data", "application.yml"), filepath.Join(dir, "application.yml"))
+	overlay := filepath.Join(dir, "application-dev.yml")
+	writeFile(t, overlay, "logging:\n  level: info\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 10)
+	files := func() []string { return []string{filepath.Join(dir, "application.yml"), overlay} }
+	if err := watchFiles(ctx, files, func() { changes <- struct{}{} }); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		change func(t *testing.T)
+		want   bool
+	}{
+		{
+			name:   "overlay written",
+			change: func(t *testing.T) { writeFile(t, overlay, "logging:\n  level: debug\n") },
+			want:   true,
+		},
+		{
+			name: "overlay replaced by a rename",
+			change: func(t *testing.T) {
+				tmp := filepath.Join(dir, ".application-dev.yml.swp")
+				writeFile(t, tmp, "logging:\n  level: warn\n")
+				if err := os.Rename(tmp, overlay); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: true,
+		},
+		{
+			name: "config map swapped",
+			change: func(t *testing.T) {
+				symlink(t, "v2", filepath.Join(dir, "..data_tmp"))
+				if err := os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: true,
+		},
+		{
+			name:   "other file",
+			change: func(t *testing.T) { writeFile(t, filepath.Join(dir, "application-prod.yml"), "x: 1\n") },
+		},
+		{
+			name: "several writes reload once",
+			change: func(t *testing.T) {
+				for i := 0; i < 3; i++ {
+					writeFile(t, overlay, "logging:\n  level: error\n")
+				}
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.change(t)
+
+			got := 0
+			timeout := time.After(4 * reloadDelay)
+			for done := false; !done; {
+				select {
+				case <-changes:
+					got++
+				case <-timeout:
+					done = true
+				}
+			}
+			if want := map[bool]int{true: 1}[tt.want]; got != want {
+				t.Errorf("%d reloads, want %d", got, want)
+			}
+		})
+	}
+}
+
+func writeFile(t *testing.T, name string, content string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func symlink(t *testing.T, target string, name string) {
+	t.Helper()
+	if err := os.Symlink(target, name); err != nil {
+		t.Fatal(err)
+	}
+}
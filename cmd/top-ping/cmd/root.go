@@ -5,8 +5,15 @@ import (
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"top-ping/pkg/utils"
 )
 
+// envPrefix env vars override config keys, e.g. TOPPING_MYSQL_PASSWORD for mysql.password
+const envPrefix = "TOPPING"
+
 var rootCmd = &cobra.Command{
 	Use:   "top-ping api server",
 	Short: "top-ping api server",
@@ -20,34 +27,74 @@ func Execute() {
 }
 
 var configFile string
-var config *viper.Viper
+
+// config the last applied config, replaced by reloads from the watcher goroutines
+var config atomic.Pointer[viper.Viper]
 
 func init() {
 	cobra.OnInitialize(func() {
 		err := initConfig()
 		if err != nil {
-			panic(err)
+			os.Exit(1)
 		}
 	})
 
 	initFlags()
 
 	rootCmd.AddCommand(serverCmd)
+	rootCmd.AddCommand(configCmd)
+	rootCmd.AddCommand(migrateCmd)
 }
 
 func initConfig() (err error) {
+	v, err := loadConfig(configFile)
+	if err != nil {
+		fmt.Println(err)
+		return err
+	}
+
+	config.Store(v)
+
+	return nil
+}
+
+// loadConfig read the base file, merge application-<profile>.yml next to it if present, then apply env overrides
+func loadConfig(file string) (*viper.Viper, error) {
 	v := viper.New()
-	v.SetConfigFile(configFile)
+	v.SetConfigFile(file)
 	v.SetConfigType("yml")
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	bindEnvs(v)
 
 	if err := v.ReadInConfig(); err != nil {
-		fmt.Println(err)
-		return err
+		return nil, err
 	}
 
-	config = v
+	profile := v.GetString("application.profile")
+	if profile == "" {
+		return v, nil
+	}
 
-	return nil
+	overlay := overlayFile(file, profile)
+	if ok, _ := utils.PathExists(overlay); !ok {
+		return v, nil
+	}
+
+	v.SetConfigFile(overlay)
+	if err := v.MergeInConfig(); err != nil {
+		return nil, fmt.Errorf("merging %s: %w", overlay, err)
+	}
+	v.SetConfigFile(file)
+
+	return v, nil
+}
+
+// overlayFile the profile config next to file, e.g. configs/application-dev.yml
+func overlayFile(file string, profile string) string {
+	ext := filepath.Ext(file)
+	return strings.TrimSuffix(file, ext) + "-" + profile + ext
 }
 
 func initFlags() {
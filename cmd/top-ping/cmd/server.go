@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"github.com/spf13/cobra"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 	"top-ping/internal/app/router"
+	"top-ping/pkg/clientip"
 	"top-ping/pkg/database"
+	"top-ping/pkg/health"
+	"top-ping/pkg/idgen"
 	"top-ping/pkg/logger"
+	"top-ping/pkg/migrate"
+	"top-ping/pkg/ratelimit"
+	"top-ping/pkg/tracing"
 )
 
 // serverCmd represents the server command
@@ -22,31 +29,59 @@ var serverCmd = &cobra.Command{
 		ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 		defer stop()
 
+		s, errs := loadSettings(config.Load())
+		if len(errs) > 0 {
+			for _, err := range errs {
+				fmt.Println(err)
+			}
+			os.Exit(1)
+		}
+
 		defer logger.Sync()
-		profile := config.GetString("application.profile")
+		profile := s.Application.Profile
+
+		logger.Init(profile, &s.Logging)
 
-		var logging logger.Config
-		loggingErr := config.UnmarshalKey("logging", &logging)
-		if loggingErr != nil {
-			panic("loading logging configuration error!!!")
+		idGenerator, _ := idgen.New(&s.TraceID)
+		idgen.SetDefault(idGenerator)
+		resolver, _ := clientip.NewResolver(&s.ClientIP)
+		clientip.SetDefault(resolver)
+
+		shutdownTracing, tracingErr := tracing.Init(ctx, &s.Tracing)
+		if tracingErr != nil {
+			logger.Fatalf(ctx, "Tracing: init failed: %v", tracingErr)
 		}
-		logger.Init(profile, &logging)
+		if err := database.Init(ctx, &s.Mysql); err != nil {
+			if !s.Server.DegradedStart {
+				logger.Fatalf(ctx, "Database: %v", err)
+			}
+			logger.Errorf(ctx, "Database: %v, starting degraded", err)
+			database.InitBackground(ctx, &s.Mysql)
+		} else if s.Server.MigrateOnStart {
+			migrator, err := migrate.New(database.Get(), s.Mysql.Driver())
+			if err == nil {
+				_, err = migrator.Up(ctx)
+			}
+			if err != nil {
+				logger.Fatalf(ctx, "Migrate: %v", err)
+			}
+		}
+
+		limiter := ratelimit.NewLimiter(&s.RateLimit)
+		watchConfig(ctx, limiter)
 
-		var mysqlConf database.DatasourceConfig
-		databaseErr := config.UnmarshalKey("mysql", &mysqlConf)
-		if databaseErr != nil {
-			panic("loading logging configuration error!!!")
+		health.SetTimeout(s.Health.Timeout)
+		health.Register("database", database.Ping)
+		if s.Logging.FileEnabled() {
+			health.Register("disk", health.DiskSpace(s.Logging.Dir, s.Health.MinFreeDiskMB))
 		}
-		database.Init(&mysqlConf)
 
-		host := config.GetString("server.host")
-		port := config.GetInt("server.port")
-		addr := fmt.Sprintf("%s:%d", host, port)
+		addr := fmt.Sprintf("%s:%d", s.Server.Host, s.Server.Port)
 
 		logger.Infof(ctx, "Server: listening on: %s", addr)
 		srv := &http.Server{
 			Addr:         addr,
-			Handler:      router.Router(profile, &logging),
+			Handler:      router.Router(profile, limiter, s.Admin.Token),
 			ReadTimeout:  10 * time.Second,
 			WriteTimeout: 30 * time.Second,
 			IdleTimeout:  30 * time.Second,
@@ -65,6 +100,13 @@ var serverCmd = &cobra.Command{
 
 		// Restore default behavior on the interrupt signal and notify user of shutdown.
 		stop()
+
+		// Fail readiness first and give load balancers time to drain this instance
+		health.SetShuttingDown()
+		if s.Server.DrainDelay > 0 {
+			logger.Infof(context.Background(), "Server: draining for %s", s.Server.DrainDelay)
+			time.Sleep(s.Server.DrainDelay)
+		}
 		//booted.Logger.Println("shutting down gracefully, press Ctrl+C again to force")
 
 		// The context is used to inform the server it has 5 seconds to finish
@@ -74,5 +116,8 @@ var serverCmd = &cobra.Command{
 		if err := srv.Shutdown(ctx); err != nil {
 			logger.Fatalf(ctx, "Server forced to shutdown: %v", err)
 		}
+		if err := shutdownTracing(ctx); err != nil {
+			logger.Errorf(ctx, "Tracing: flushing spans failed: %v", err)
+		}
 	},
 }
@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"fmt"
+	"github.com/spf13/viper"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"top-ping/pkg/clientip"
+	"top-ping/pkg/database"
+	"top-ping/pkg/health"
+	"top-ping/pkg/idgen"
+	"top-ping/pkg/logger"
+	"top-ping/pkg/ratelimit"
+	"top-ping/pkg/tracing"
+	"top-ping/pkg/utils"
+)
+
+// settings schema of application.yml, keys not declared here are reported as unknown
+type settings struct {
+	Application applicationConfig         `mapstructure:"application"`
+	Server      serverConfig              `mapstructure:"server"`
+	Logging     logger.Config             `mapstructure:"logging"`
+	Mysql       database.DatasourceConfig `mapstructure:"mysql"`
+	RateLimit   ratelimit.Config          `mapstructure:"rateLimit"`
+	Health      health.Config             `mapstructure:"health"`
+	Admin       adminConfig               `mapstructure:"admin"`
+	Tracing     tracing.Config            `mapstructure:"tracing"`
+	TraceID     idgen.Config              `mapstructure:"traceId"`
+	ClientIP    clientip.Config           `mapstructure:"clientIp"`
+}
+
+type applicationConfig struct {
+	Profile string `mapstructure:"profile"`
+}
+
+type adminConfig struct {
+	// Token bearer token of the /admin endpoints, they are disabled when empty
+	Token string `mapstructure:"token"`
+}
+
+type serverConfig struct {
+	Host       string        `mapstructure:"host"`
+	Port       int           `mapstructure:"port"`
+	DrainDelay time.Duration `mapstructure:"drainDelay"`
+	// DegradedStart start even if the database is down, readiness fails until it connects
+	DegradedStart bool `mapstructure:"degradedStart"`
+	// MigrateOnStart apply pending migrations before serving, instances take turns on the advisory lock
+	MigrateOnStart bool `mapstructure:"migrateOnStart"`
+}
+
+func (c *applicationConfig) Validate() error {
+	switch c.Profile {
+	case utils.DevProfile, utils.TestProfile, utils.ProdProfile:
+		return nil
+	}
+	return fmt.Errorf("application.profile: must be one of %s, %s, %s, got %q",
+		utils.DevProfile, utils.TestProfile, utils.ProdProfile, c.Profile)
+}
+
+func (c *serverConfig) Validate() error {
+	if c.Port <= 0 || c.Port > 65535 {
+		return fmt.Errorf("server.port: must be between 1 and 65535, got %d", c.Port)
+	}
+	return nil
+}
+
+// loadSettings decode and validate the whole config, all problems are returned at once
+func loadSettings(v *viper.Viper) (*settings, []error) {
+	var errs []error
+	for _, key := range unknownKeys(v, reflect.TypeOf(settings{})) {
+		errs = append(errs, fmt.Errorf("%s: unknown key", key))
+	}
+
+	var s settings
+	if err := v.Unmarshal(&s); err != nil {
+		return nil, append(errs, err)
+	}
+
+	validators := []interface{ Validate() error }{
+		&s.Application,
+		&s.Server,
+		&s.Logging,
+		&s.Mysql,
+		&s.RateLimit,
+		&s.Tracing,
+		&s.TraceID,
+		&s.ClientIP,
+	}
+	for _, validator := range validators {
+		if err := validator.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return &s, nil
+}
+
+// bindEnvs bind every schema key to its env var, so TOPPING_MYSQL_PASSWORD works even if the key is not in the file
+func bindEnvs(v *viper.Viper) {
+	for _, key := range schemaKeys(reflect.TypeOf(settings{}), "") {
+		_ = v.BindEnv(key)
+	}
+}
+
+func unknownKeys(v *viper.Viper, schema reflect.Type) []string {
+	var keys []string
+	for _, key := range v.AllKeys() {
+		if !knownKey(schema, strings.Split(key, ".")) {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+	return keys
+}
+
+func knownKey(t reflect.Type, path []string) bool {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if len(path) == 0 {
+		return true
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		for _, field := range configFields(t) {
+			if strings.EqualFold(fieldKey(field), path[0]) {
+				return knownKey(field.Type, path[1:])
+			}
+		}
+	case reflect.Map:
+		// map keys are free form, e.g. rateLimit.groups.<name>
+		return knownKey(t.Elem(), path[1:])
+	}
+
+	return false
+}
+
+func schemaKeys(t reflect.Type, prefix string) []string {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return []string{prefix}
+	}
+
+	var keys []string
+	for _, field := range configFields(t) {
+		if field.Type.Kind() == reflect.Map {
+			continue
+		}
+
+		key := strings.ToLower(fieldKey(field))
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		keys = append(keys, schemaKeys(field.Type, key)...)
+	}
+
+	return keys
+}
+
+// configFields the fields of struct t decoded from the config, unexported ones are state, e.g. compiled regexps
+func configFields(t reflect.Type) []reflect.StructField {
+	var fields []reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.IsExported() && fieldKey(field) != "-" {
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+func fieldKey(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("mapstructure"), ",", 2)[0]
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
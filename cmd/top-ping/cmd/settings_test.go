@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+type sampleSettings struct {
+	Name  string `mapstructure:"name"`
+	Inner struct {
+		Port    int           `mapstructure:"port"`
+		Timeout time.Duration `mapstructure:"timeout"`
+		secret  string
+	} `mapstructure:"inner"`
+	Groups   map[string]struct{ Rate int } `mapstructure:"groups"`
+	NoTag    bool
+	Ignored  string `mapstructure:"-"`
+	compiled *regexp.Regexp
+	cache    map[string]string
+}
+
+func TestSchemaKeys(t *testing.T) {
+	got := schemaKeys(reflect.TypeOf(sampleSettings{}), "")
+	want := []string{"name", "inner.port", "inner.timeout", "notag"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("schemaKeys() = %v, want %v", got, want)
+	}
+
+	tests := []struct {
+		key   string
+		known bool
+	}{
+		{"name", true},
+		{"inner", true},
+		{"inner.port", true},
+		{"inner.secret", false},
+		{"groups.a.rate", true},
+		{"noTag", true},
+		{"ignored", false},
+		{"compiled", false},
+		{"cache.x", false},
+		{"other", false},
+	}
+	for _, tt := range tests {
+		if got := knownKey(reflect.TypeOf(sampleSettings{}), strings.Split(strings.ToLower(tt.key), ".")); got != tt.known {
+			t.Errorf("knownKey(%s) = %v, want %v", tt.key, got, tt.known)
+		}
+	}
+}
+
+func TestSettingsKeys(t *testing.T) {
+	keys := schemaKeys(reflect.TypeOf(settings{}), "")
+	set := map[string]bool{}
+	for _, key := range keys {
+		if set[key] {
+			t.Errorf("key %s twice", key)
+		}
+		set[key] = true
+		if key != strings.ToLower(key) {
+			t.Errorf("key %s not lower case as viper keys are", key)
+		}
+	}
+
+	for _, key := range []string{"application.profile", "server.port", "mysql.password", "logging.level", "logging.body.maxsize", "admin.token"} {
+		if !set[key] {
+			t.Errorf("schemaKeys() has no %s", key)
+		}
+	}
+	// unexported state of logger.Config
+	for _, key := range []string{"logging.skippathregexps", "logging.masker"} {
+		if set[key] {
+			t.Errorf("schemaKeys() has %s of an unexported field", key)
+		}
+		if knownKey(reflect.TypeOf(settings{}), strings.Split(key, ".")) {
+			t.Errorf("knownKey(%s) of an unexported field", key)
+		}
+	}
+}
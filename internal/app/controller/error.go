@@ -0,0 +1,13 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"top-ping/pkg/baseerr"
+	"top-ping/pkg/rest"
+)
+
+// ListErrors all error codes with http status and translations, for clients to generate their error tables
+func ListErrors(c *gin.Context) {
+	lang := baseerr.MatchLang(c.GetHeader("Accept-Language"))
+	rest.R.Success(c, baseerr.Definitions(lang))
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"top-ping/pkg/baseerr"
+)
+
+func TestListErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/v1/errors", ListErrors)
+
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", "Record not found"},
+		{"zh-TW,zh;q=0.9", "记录不存在"},
+		{"fr, en;q=0.5", "Record not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.acceptLanguage, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/errors", nil)
+			req.Header.Set("Accept-Language", tt.acceptLanguage)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var res struct {
+				Code int                  `json:"code"`
+				Data []baseerr.Definition `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("response %s: %v", w.Body, err)
+			}
+			if w.Code != http.StatusOK || res.Code != baseerr.Success.Code() {
+				t.Fatalf("status %d, code %d, want a success", w.Code, res.Code)
+			}
+			if len(res.Data) != len(baseerr.Definitions(baseerr.DefaultLang)) {
+				t.Errorf("%d codes, want all of them", len(res.Data))
+			}
+
+			for _, d := range res.Data {
+				if d.Code != baseerr.ErrNotFound.Code() {
+					continue
+				}
+				if d.Message != tt.want || d.Status != http.StatusNotFound || d.Messages[baseerr.LangZhCN] != "记录不存在" {
+					t.Errorf("ErrNotFound = %+v, want message %q, status 404 and every translation", d, tt.want)
+				}
+				return
+			}
+			t.Error("ErrNotFound is not in the catalog")
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net/http"
+	"top-ping/pkg/health"
+	"top-ping/pkg/logger"
+)
+
+// Healthz liveness, the process is alive as long as it can answer
+func Healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// Readyz readiness with the status of every dependency check, the errors are logged and shown by ReadyzDetail only
+func Readyz(c *gin.Context) {
+	ctx := c.Request.Context()
+	report, ready := health.Readiness(ctx)
+	if !ready {
+		for name, result := range report.Checks {
+			if result.Error != "" {
+				logger.Warn(ctx, "ReadinessCheckFailed", zap.String("Check", name), zap.String("Error", result.Error))
+			}
+		}
+	}
+
+	c.JSON(readinessStatus(ready), report.Public())
+}
+
+// ReadyzDetail readiness with the errors and latencies of the checks, for the admin route
+func ReadyzDetail(c *gin.Context) {
+	report, ready := health.Readiness(c.Request.Context())
+	c.JSON(readinessStatus(ready), report)
+}
+
+func readinessStatus(ready bool) int {
+	if !ready {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
+}
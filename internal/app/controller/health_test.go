@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"top-ping/pkg/health"
+	"top-ping/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	disabled := false
+	logger.Init("test", &logger.Config{Level: "error", Stdout: logger.OutputConfig{Enabled: &disabled}, File: logger.OutputConfig{Enabled: &disabled}})
+	os.Exit(m.Run())
+}
+
+func TestReadyz(t *testing.T) {
+	const secret = "dial tcp 10.0.0.5:3306: access denied for user 'app'"
+	health.Register("test-up", func(ctx context.Context) error { return nil })
+	health.Register("test-down", func(ctx context.Context) error { return errors.New(secret) })
+
+	r := gin.New()
+	r.GET("/readyz", Readyz)
+	r.GET("/admin/readyz", ReadyzDetail)
+
+	tests := []struct {
+		path       string
+		wantDetail bool
+	}{
+		{path: "/readyz"},
+		{path: "/admin/readyz", wantDetail: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != http.StatusServiceUnavailable {
+				t.Errorf("status %d, want 503", w.Code)
+			}
+
+			var report health.Report
+			if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+				t.Fatalf("response %s: %v", w.Body, err)
+			}
+			if report.Status != health.StatusDown || report.Checks["test-up"].Status != health.StatusUp || report.Checks["test-down"].Status != health.StatusDown {
+				t.Errorf("report %+v, want test-down down and test-up up", report)
+			}
+
+			detailed := strings.Contains(w.Body.String(), secret) || strings.Contains(w.Body.String(), "latency")
+			if detailed != tt.wantDetail {
+				t.Errorf("response %s, want errors and latencies %v", w.Body, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package controller
+
+import (
+	"github.com/gin-gonic/gin"
+	"time"
+	"top-ping/pkg/baseerr"
+	"top-ping/pkg/logger"
+	"top-ping/pkg/rest"
+)
+
+type setLogLevelRequest struct {
+	Level string `json:"level" binding:"required,oneof=debug info warn error dpanic panic fatal"`
+	// TTL e.g. "15m", the level reverts to the configured one afterwards, empty means no expiry
+	TTL string `json:"ttl"`
+}
+
+// GetLogLevel current log level and whether it is a temporary override
+func GetLogLevel(c *gin.Context) {
+	rest.R.Success(c, logger.GetLevelStatus())
+}
+
+// SetLogLevel override the log level at runtime
+func SetLogLevel(c *gin.Context) {
+	var req setLogLevelRequest
+	if err := rest.BindJSON(c, &req); err != nil {
+		rest.R.Error(c, err)
+		return
+	}
+
+	var ttl time.Duration
+	if req.TTL != "" {
+		var err error
+		ttl, err = time.ParseDuration(req.TTL)
+		if err != nil || ttl < 0 {
+			rest.R.Error(c, baseerr.ErrInvalidParam.WithDetails(baseerr.FieldError{
+				Field:   "ttl",
+				Rule:    "duration",
+				Message: "must be a positive duration such as 15m",
+			}))
+			return
+		}
+	}
+
+	if err := logger.OverrideLevel(req.Level, ttl); err != nil {
+		rest.R.Error(c, baseerr.ErrInvalidParam.Wrap(err))
+		return
+	}
+
+	logger.Warnf(c.Request.Context(), "Logger: level overridden to %s for %s", req.Level, ttlText(ttl))
+	rest.R.Success(c, logger.GetLevelStatus())
+}
+
+// ResetLogLevel drop the override and go back to the configured level
+func ResetLogLevel(c *gin.Context) {
+	logger.ResetLevel()
+	rest.R.Success(c, logger.GetLevelStatus())
+}
+
+func ttlText(ttl time.Duration) string {
+	if ttl == 0 {
+		return "ever"
+	}
+	return ttl.String()
+}
@@ -1,55 +1,53 @@
 package middleware
 
 import (
-	"bytes"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"io/ioutil"
-	"regexp"
 	"strings"
+	"top-ping/pkg/idgen"
 	"top-ping/pkg/logger"
+	"top-ping/pkg/tracing"
 	"top-ping/pkg/utils"
 )
 
-func AccessLogger(config *logger.Config) gin.HandlerFunc {
+func AccessLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		config := logger.CurrentConfig()
 		path := c.Request.URL.Path
-		skipPaths := config.SkipPaths
-		for _, skipPath := range skipPaths {
-			reg := regexp.MustCompile(skipPath)
-			if reg.MatchString(path) {
-				c.Next()
-				return
-			}
+		if config.SkipPath(path) {
+			c.Next()
+			return
 		}
 
-		var traceId string
-		headerTraceId := c.Request.Header.Get(utils.TraceKey)
-		if headerTraceId == "" {
-			traceId = utils.RandomString(utils.TraceLen)
-		} else {
-			traceId = headerTraceId
+		// the W3C trace id of the span wins over the legacy TraceID header, which is only trusted when valid
+		traceId := tracing.TraceID(c.Request.Context())
+		if traceId == "" {
+			if header := c.Request.Header.Get(utils.TraceKey); idgen.Valid(header) {
+				traceId = header
+			}
 		}
-
-		ctx := logger.WithTrace(c.Request.Context(), strings.ToLower(traceId))
-		c.Request = c.Request.WithContext(ctx)
-
-		var bodyBytes []byte
-		if c.Request.Body != nil {
-			bodyBytes, _ = ioutil.ReadAll(c.Request.Body)
+		if traceId == "" {
+			traceId = idgen.NewID()
 		}
-		c.Request.Body = ioutil.NopCloser(bytes.NewBuffer(bodyBytes))
+		traceId = strings.ToLower(traceId)
+		c.Header(utils.TraceKey, traceId)
 
-		request := string(bodyBytes)
-		if config.Desensitize {
-			request = utils.MaskJsonStr(&request, config.SkipFields)
+		ctx := logger.WithTrace(c.Request.Context(), traceId)
+		if spanId := tracing.SpanID(ctx); spanId != "" {
+			ctx = logger.WithFields(ctx, zap.String("SpanID", spanId))
+		}
+		if route := c.FullPath(); route != "" {
+			ctx = logger.WithFields(ctx, zap.String("Route", route))
 		}
+		c.Request = c.Request.WithContext(ctx)
+
+		request := captureRequestBody(c, config)
 
-		header := utils.MaskHttpHeader(c.Request.Header, []string{"Authentication"})
+		header := config.RedactHeader(c.Request.Header)
 
-		logger.Info(ctx, "AccessLog",
+		logger.Access(ctx, "AccessLog",
 			zap.String("Method", c.Request.Method),
-			zap.String("IP", c.ClientIP()),
+			zap.String("IP", utils.GetRealIP(c)),
 			zap.String("Path", path),
 			zap.Any("Header", header),
 			zap.String("Query", c.Request.URL.RawQuery),
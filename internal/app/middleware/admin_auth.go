@@ -0,0 +1,24 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"strings"
+	"top-ping/pkg/baseerr"
+	"top-ping/pkg/rest"
+)
+
+// AdminAuth require "Authorization: Bearer <token>", all requests are refused when no token is configured
+func AdminAuth(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		auth := c.GetHeader("Authorization")
+		given := strings.TrimPrefix(auth, "Bearer ")
+		if token == "" || given == auth || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			rest.R.Error(c, baseerr.ErrInvalidToken)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"top-ping/pkg/logger"
+)
+
+// bodyLogWriter keep the first maxSize bytes of the response for the log, the client gets all of them
+type bodyLogWriter struct {
+	gin.ResponseWriter
+	body    *bytes.Buffer
+	maxSize int
+	size    int
+	// capture decided on the first write, once the content type is set
+	capture *bool
+	config  *logger.Config
+}
+
+func (w *bodyLogWriter) Write(b []byte) (int, error) {
+	w.keep(b)
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *bodyLogWriter) WriteString(s string) (int, error) {
+	w.keep([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *bodyLogWriter) keep(b []byte) {
+	if w.capture == nil {
+		capture := bodyLogged(w.config, w.Header())
+		w.capture = &capture
+	}
+
+	w.size += len(b)
+	if !*w.capture {
+		return
+	}
+	if room := w.maxSize - w.body.Len(); room > 0 {
+		if len(b) > room {
+			b = b[:room]
+		}
+		w.body.Write(b)
+	}
+}
+
+// String the captured body, or a marker of why it isn't there
+func (w *bodyLogWriter) String() string {
+	if w.capture != nil && !*w.capture {
+		return skippedMarker(w.Header().Get("Content-Type"), w.size)
+	}
+	return logBody(w.config, w.Header().Get("Content-Type"), w.body.Bytes(), w.size > w.body.Len(), w.size)
+}
+
+// captureRequestBody read up to maxSize bytes of the request body for the log, the handler still reads all of it
+func captureRequestBody(c *gin.Context, config *logger.Config) string {
+	req := c.Request
+	if req.Body == nil || req.Body == http.NoBody {
+		return ""
+	}
+	if !bodyLogged(config, req.Header) {
+		return skippedMarker(req.Header.Get("Content-Type"), int(req.ContentLength))
+	}
+
+	maxSize := config.BodyMaxSize()
+	captured, _ := io.ReadAll(io.LimitReader(req.Body, int64(maxSize)+1))
+	req.Body = readCloser{Reader: io.MultiReader(bytes.NewReader(captured), req.Body), Closer: req.Body}
+
+	truncated := len(captured) > maxSize
+	if truncated {
+		captured = captured[:maxSize]
+	}
+	return logBody(config, req.Header.Get("Content-Type"), captured, truncated, int(req.ContentLength))
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+func bodyLogged(config *logger.Config, header http.Header) bool {
+	if encoding := header.Get("Content-Encoding"); encoding != "" && encoding != "identity" {
+		return false
+	}
+	return config.BodyLogged(header.Get("Content-Type"))
+}
+
+// logBody desensitize body by its content type, json and form bodies are masked by key.
+// Other bodies and truncated ones can't be desensitized and are left out.
+func logBody(config *logger.Config, contentType string, body []byte, truncated bool, size int) string {
+	if !config.Desensitize || config.Masker().Empty() {
+		if truncated {
+			return fmt.Sprintf("%s...[truncated, %s]", body, sizeOf(size))
+		}
+		return string(body)
+	}
+	if truncated {
+		return fmt.Sprintf("[truncated, %s, not logged as it can't be desensitized]", sizeOf(size))
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		if masked, err := config.Masker().MaskForm(string(body)); err == nil {
+			return masked
+		}
+	case mediaType == "" || mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		if json.Valid(body) {
+			return config.Masker().MaskJSON(string(body))
+		}
+	}
+
+	if len(body) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("[%s body, %s, not logged as it can't be desensitized]", contentTypeOf(mediaType), sizeOf(size))
+}
+
+func contentTypeOf(mediaType string) string {
+	if mediaType == "" {
+		return "unknown"
+	}
+	return mediaType
+}
+
+func skippedMarker(contentType string, size int) string {
+	return fmt.Sprintf("[skipped %s body, %s]", contentType, sizeOf(size))
+}
+
+// sizeOf size is -1 when unknown, e.g. a chunked request
+func sizeOf(size int) string {
+	if size < 0 {
+		return "unknown size"
+	}
+	return fmt.Sprintf("%d bytes", size)
+}
@@ -0,0 +1,142 @@
+package middleware
+
+import (
+	"bytes"
+	"github.com/gin-gonic/gin"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"top-ping/pkg/logger"
+)
+
+var (
+	plainConfig  = &logger.Config{Body: logger.BodyConfig{MaxSize: 16}}
+	maskedConfig = &logger.Config{Desensitize: true, SkipFields: []string{"password"}, Body: logger.BodyConfig{MaxSize: 16}}
+)
+
+func TestCaptureRequestBody(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	tests := []struct {
+		name        string
+		config      *logger.Config
+		contentType string
+		encoding    string
+		body        string
+		want        string
+	}{
+		{name: "empty", config: maskedConfig, contentType: "application/json", want: ""},
+		{name: "json masked", config: maskedConfig, contentType: "application/json", body: `{"password":"s"}`, want: `{"password":"***"}`},
+		{name: "form masked", config: maskedConfig, contentType: "application/x-www-form-urlencoded", body: "a=1&password=s", want: "a=1&password=***"},
+		{name: "json kept without desensitize", config: plainConfig, contentType: "application/json", body: `{"password":"s"}`, want: `{"password":"s"}`},
+		{name: "over the limit", config: plainConfig, contentType: "text/plain", body: long, want: long[:16] + "...[truncated, 40 bytes]"},
+		{name: "over the limit with desensitize", config: maskedConfig, contentType: "application/json", body: `{"k":"` + long + `"}`, want: "[truncated, 48 bytes, not logged as it can't be desensitized]"},
+		{name: "multipart skipped", config: plainConfig, contentType: "multipart/form-data; boundary=x", body: "--x\r\n", want: "[skipped multipart/form-data; boundary=x body, 5 bytes]"},
+		{name: "binary skipped", config: plainConfig, contentType: "application/octet-stream", body: "\x00\x01\x02", want: "[skipped application/octet-stream body, 3 bytes]"},
+		{name: "compressed skipped", config: plainConfig, contentType: "application/json", encoding: "gzip", body: "\x1f\x8b", want: "[skipped application/json body, 2 bytes]"},
+		{name: "text can't be desensitized", config: maskedConfig, contentType: "text/plain", body: "password", want: "[text/plain body, 8 bytes, not logged as it can't be desensitized]"},
+		{name: "invalid json can't be desensitized", config: maskedConfig, contentType: "application/json", body: `{"password":`, want: "[application/json body, 12 bytes, not logged as it can't be desensitized]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+			if tt.encoding != "" {
+				req.Header.Set("Content-Encoding", tt.encoding)
+			}
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = req
+
+			if got := captureRequestBody(c, tt.config); got != tt.want {
+				t.Errorf("captureRequestBody() = %q, want %q", got, tt.want)
+			}
+			// the handler still reads the whole body
+			if body, _ := io.ReadAll(c.Request.Body); string(body) != tt.body {
+				t.Errorf("handler read %q, want %q", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestBodyLogWriter(t *testing.T) {
+	long := strings.Repeat("x", 40)
+	tests := []struct {
+		name    string
+		config  *logger.Config
+		handler gin.HandlerFunc
+		// body the client gets
+		body string
+		want string
+	}{
+		{
+			name:    "json masked",
+			config:  maskedConfig,
+			handler: func(c *gin.Context) { c.Data(http.StatusOK, "application/json", []byte(`{"password":"s"}`)) },
+			body:    `{"password":"s"}`,
+			want:    `{"password":"***"}`,
+		},
+		{
+			name:    "truncated",
+			config:  plainConfig,
+			handler: func(c *gin.Context) { c.String(http.StatusOK, long) },
+			body:    long,
+			want:    long[:16] + "...[truncated, 40 bytes]",
+		},
+		{
+			name:   "truncated across writes",
+			config: plainConfig,
+			handler: func(c *gin.Context) {
+				c.Header("Content-Type", "text/plain")
+				c.Writer.WriteString(long[:10])
+				c.Writer.Write([]byte(long[10:]))
+			},
+			body: long,
+			want: long[:16] + "...[truncated, 40 bytes]",
+		},
+		{
+			name:    "truncated with desensitize",
+			config:  maskedConfig,
+			handler: func(c *gin.Context) { c.Data(http.StatusOK, "application/json", []byte(`{"k":"`+long+`"}`)) },
+			body:    `{"k":"` + long + `"}`,
+			want:    "[truncated, 48 bytes, not logged as it can't be desensitized]",
+		},
+		{
+			name:    "binary skipped",
+			config:  plainConfig,
+			handler: func(c *gin.Context) { c.Data(http.StatusOK, "image/png", []byte(long)) },
+			body:    long,
+			want:    "[skipped image/png body, 40 bytes]",
+		},
+		{
+			name:    "no body",
+			config:  plainConfig,
+			handler: func(c *gin.Context) { c.Status(http.StatusNoContent) },
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			r := gin.New()
+			r.Use(func(c *gin.Context) {
+				blw := &bodyLogWriter{body: &bytes.Buffer{}, maxSize: tt.config.BodyMaxSize(), ResponseWriter: c.Writer, config: tt.config}
+				c.Writer = blw
+				c.Next()
+				got = blw.String()
+			})
+			r.GET("/", tt.handler)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Body.String() != tt.body {
+				t.Errorf("client got %q, want %q", w.Body.String(), tt.body)
+			}
+			if got != tt.want {
+				t.Errorf("logged %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
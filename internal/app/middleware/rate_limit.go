@@ -0,0 +1,54 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"math"
+	"strconv"
+	"time"
+	"top-ping/pkg/baseerr"
+	"top-ping/pkg/ratelimit"
+	"top-ping/pkg/rest"
+	"top-ping/pkg/utils"
+)
+
+// RateLimiter limit requests of the route group per authenticated api key, user or client ip.
+// Register it after the auth middlewares of the group.
+func RateLimiter(limiter *ratelimit.Limiter, group string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		result, ok := limiter.Take(group, rateLimitKey(c))
+		if !ok {
+			c.Next()
+			return
+		}
+
+		header := c.Writer.Header()
+		header.Set("X-RateLimit-Limit", strconv.Itoa(result.Limit))
+		header.Set("X-RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("X-RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			header.Set("Retry-After", strconv.Itoa(ceilSeconds(result.RetryAfter)))
+			rest.R.Error(c, baseerr.ErrTooManyRequests)
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// rateLimitKey only identities set by the auth middlewares are trusted, a raw api key header could be changed per request
+func rateLimitKey(c *gin.Context) string {
+	if apiKey := c.GetString(utils.ApiKeyKey); apiKey != "" {
+		return "key:" + apiKey
+	}
+	if userID := c.GetString(utils.UserKey); userID != "" {
+		return "user:" + userID
+	}
+
+	return "ip:" + utils.GetRealIP(c)
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"net"
+	"os"
+	"runtime/debug"
+	"strings"
+	"top-ping/pkg/baseerr"
+	"top-ping/pkg/logger"
+	"top-ping/pkg/rest"
+)
+
+// Recovery recover from panics, log them with the trace id and respond with the standard error body
+func Recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			if err := recover(); err != nil {
+				// c.Request is read here so the trace set by AccessLogger is picked up
+				ctx := c.Request.Context()
+				brokenPipe := isBrokenPipe(err)
+
+				logger.Error(ctx, "PanicRecoveredLog",
+					zap.Any("Error", err),
+					zap.String("Method", c.Request.Method),
+					zap.String("Path", c.Request.URL.Path),
+					zap.Bool("BrokenPipe", brokenPipe),
+					zap.String("Stack", string(debug.Stack())),
+				)
+
+				// the connection is dead or the response is already on the wire, nothing more can be sent
+				if brokenPipe || c.Writer.Written() {
+					c.Abort()
+					return
+				}
+
+				rest.R.Error(c, baseerr.ErrInternalServer)
+				c.Abort()
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+func isBrokenPipe(err interface{}) bool {
+	e, ok := err.(error)
+	if !ok {
+		return false
+	}
+
+	var ne *net.OpError
+	if !errors.As(e, &ne) {
+		return false
+	}
+
+	var se *os.SyscallError
+	if errors.As(ne, &se) {
+		msg := strings.ToLower(se.Error())
+		return strings.Contains(msg, "broken pipe") || strings.Contains(msg, "connection reset by peer")
+	}
+
+	return false
+}
@@ -4,54 +4,35 @@ import (
 	"bytes"
 	"github.com/gin-gonic/gin"
 	"go.uber.org/zap"
-	"regexp"
+	"math/rand"
 	"time"
 	"top-ping/pkg/logger"
-	"top-ping/pkg/utils"
 )
 
-type bodyLogWriter struct {
-	gin.ResponseWriter
-	body *bytes.Buffer
-}
-
-func (w bodyLogWriter) Write(b []byte) (int, error) {
-	w.body.Write(b)
-	return w.ResponseWriter.Write(b)
-}
-
-func (w bodyLogWriter) WriteString(s string) (int, error) {
-	w.body.WriteString(s)
-	return w.ResponseWriter.WriteString(s)
-}
-
-func ResponseLogger(config *logger.Config) gin.HandlerFunc {
+func ResponseLogger() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		config := logger.CurrentConfig()
 		path := c.Request.URL.Path
-		skipPaths := config.SkipPaths
-		for _, skipPath := range skipPaths {
-			reg := regexp.MustCompile(skipPath)
-			if reg.MatchString(path) {
-				c.Next()
-				return
-			}
+		if config.SkipPath(path) {
+			c.Next()
+			return
 		}
 
 		start := time.Now()
-		blw := &bodyLogWriter{body: bytes.NewBufferString(""), ResponseWriter: c.Writer}
+		blw := &bodyLogWriter{body: &bytes.Buffer{}, maxSize: config.BodyMaxSize(), ResponseWriter: c.Writer, config: config}
 		c.Writer = blw
 		c.Next()
 
 		cost := time.Since(start)
-		responseBody := blw.body.String()
-		if config.Desensitize {
-			responseBody = utils.MaskJsonStr(&responseBody, config.SkipFields)
+		status := blw.Status()
+		if rate := config.SampleRate(status); rate < 1 && rand.Float64() >= rate {
+			return
 		}
 
-		logger.Info(c.Request.Context(), "ResponseLog",
-			zap.Int("Status", c.Writer.Status()),
+		logger.Access(c.Request.Context(), "ResponseLog",
+			zap.Int("Status", status),
 			zap.String("Path", path),
-			zap.String("Response", responseBody),
+			zap.String("Response", blw.String()),
 			zap.Duration("Cost", cost),
 		)
 	}
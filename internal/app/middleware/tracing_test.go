@@ -0,0 +1,170 @@
+package middleware
+
+import (
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+	"top-ping/pkg/logger"
+	"top-ping/pkg/tracing"
+	"top-ping/pkg/tracing/tracingtest"
+	"top-ping/pkg/utils"
+)
+
+const (
+	parentTraceId = "4bf92f3577b34da6a3ce929d0e0e4736"
+	parentSpanId  = "00f067aa0ba902b7"
+)
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	disabled := false
+	logger.Init("test", &logger.Config{Level: "error", Stdout: logger.OutputConfig{Enabled: &disabled}, File: logger.OutputConfig{Enabled: &disabled}})
+	os.Exit(m.Run())
+}
+
+// tracedRouter GET /items/:id answering the status of the query and the trace id of the handler's ctx in X-Handler-Trace
+func tracedRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(Tracing(), AccessLogger())
+	r.GET("/items/:id", func(c *gin.Context) {
+		status, _ := strconv.Atoi(c.Query("status"))
+		if status == 0 {
+			status = http.StatusOK
+		}
+		c.Header("X-Handler-Trace", tracing.TraceID(c.Request.Context()))
+		c.Status(status)
+	})
+	return r
+}
+
+func TestTracing(t *testing.T) {
+	tests := []struct {
+		name       string
+		path       string
+		header     map[string]string
+		wantParent bool
+		wantState  string
+		wantError  bool
+	}{
+		{name: "new trace", path: "/items/1"},
+		{
+			name:       "continues traceparent and tracestate",
+			path:       "/items/1",
+			header:     map[string]string{"traceparent": "00-" + parentTraceId + "-" + parentSpanId + "-01", "tracestate": "vendor=abc"},
+			wantParent: true,
+			wantState:  "vendor=abc",
+		},
+		{
+			name:       "invalid traceparent starts a new trace",
+			path:       "/items/1",
+			header:     map[string]string{"traceparent": "00-" + parentTraceId + "-0000000000000000-01"},
+			wantParent: false,
+		},
+		{
+			name:   "span wins over the legacy header",
+			path:   "/items/1",
+			header: map[string]string{utils.TraceKey: "abcd1234ef"},
+		},
+		{name: "server error", path: "/items/1?status=500", wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracingtest.Install(t)
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			tracedRouter().ServeHTTP(w, req)
+
+			spans := exporter.GetSpans()
+			if len(spans) != 1 {
+				t.Fatalf("%d spans, want 1", len(spans))
+			}
+			span := spans[0]
+			if span.Name != "GET /items/:id" || span.SpanKind != trace.SpanKindServer {
+				t.Errorf("span %q of kind %v, want GET /items/:id of kind server", span.Name, span.SpanKind)
+			}
+
+			traceId := span.SpanContext.TraceID().String()
+			if tt.wantParent {
+				if traceId != parentTraceId || span.Parent.SpanID().String() != parentSpanId || !span.Parent.IsRemote() {
+					t.Errorf("span of trace %s, parent %s, want trace %s, remote parent %s", traceId, span.Parent.SpanID(), parentTraceId, parentSpanId)
+				}
+			} else if span.Parent.IsValid() {
+				t.Errorf("span has parent %s, want a new trace", span.Parent.SpanID())
+			}
+			if got := span.SpanContext.TraceState().String(); got != tt.wantState {
+				t.Errorf("tracestate %q, want %q", got, tt.wantState)
+			}
+
+			// the legacy header and the handler's ctx carry the W3C trace id
+			if got := w.Header().Get(utils.TraceKey); got != traceId {
+				t.Errorf("%s header %q, want the trace id %s", utils.TraceKey, got, traceId)
+			}
+			if got := w.Header().Get("X-Handler-Trace"); got != traceId {
+				t.Errorf("trace id in the handler %q, want %s", got, traceId)
+			}
+
+			if (span.Status.Code == codes.Error) != tt.wantError {
+				t.Errorf("span status %v, want error %v", span.Status.Code, tt.wantError)
+			}
+			var status attribute.Value
+			for _, kv := range span.Attributes {
+				if kv.Key == "http.response.status_code" {
+					status = kv.Value
+				}
+			}
+			if status.AsInt64() != int64(w.Code) {
+				t.Errorf("http.response.status_code %v, want %d", status.Emit(), w.Code)
+			}
+		})
+	}
+}
+
+func TestLegacyTraceId(t *testing.T) {
+	// tracing disabled, nothing records spans
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(noop.NewTracerProvider())
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   string
+	}{
+		{name: "valid legacy header", header: map[string]string{utils.TraceKey: "abcd1234ef"}, want: "abcd1234ef"},
+		{name: "legacy header lowercased", header: map[string]string{utils.TraceKey: "ABCD1234EF"}, want: "abcd1234ef"},
+		{name: "traceparent still propagated", header: map[string]string{"traceparent": "00-" + parentTraceId + "-" + parentSpanId + "-01", utils.TraceKey: "abcd1234ef"}, want: parentTraceId},
+		{name: "invalid legacy header", header: map[string]string{utils.TraceKey: "bad id"}},
+		{name: "none"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/items/1", nil)
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			tracedRouter().ServeHTTP(w, req)
+
+			got := w.Header().Get(utils.TraceKey)
+			if tt.want != "" && got != tt.want {
+				t.Errorf("%s header %q, want %q", utils.TraceKey, got, tt.want)
+			}
+			if tt.want == "" && (got == "" || got == tt.header[utils.TraceKey]) {
+				t.Errorf("%s header %q, want a new id", utils.TraceKey, got)
+			}
+		})
+	}
+}
@@ -1,38 +1,61 @@
 package router
 
 import (
+	"expvar"
 	"github.com/gin-contrib/pprof"
 	"github.com/gin-gonic/gin"
+	"top-ping/internal/app/controller"
 	"top-ping/internal/app/middleware"
 	"top-ping/pkg/logger"
+	"top-ping/pkg/ratelimit"
 	"top-ping/pkg/rest"
 	"top-ping/pkg/utils"
 )
 
-func Router(profile string, logging *logger.Config) *gin.Engine {
+func Router(profile string, limiter *ratelimit.Limiter, adminToken string) *gin.Engine {
 	if profile == utils.ProdProfile {
 		gin.SetMode(gin.ReleaseMode)
 		gin.DisableConsoleColor()
 	}
 
 	var r = gin.New()
+	// client ips come from utils.GetRealIP, c.ClientIP is the peer address so it can't be spoofed either
+	_ = r.SetTrustedProxies(nil)
 	if profile != utils.ProdProfile {
 		pprof.Register(r)
 		//r.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
-	r.Use(middleware.AccessLogger(logging))
-	r.Use(middleware.ResponseLogger(logging))
-	r.Use(gin.Recovery())
+	r.Use(middleware.Tracing())
+	r.Use(middleware.AccessLogger())
+	r.Use(middleware.ResponseLogger())
+	// inside the loggers and the span, the 500 answered to a panic is logged and traced like any response
+	r.Use(middleware.Recovery())
 
 	apiV1 := r.Group("/v1")
-	apiV1.Use()
+	apiV1.Use(middleware.RateLimiter(limiter, "v1"))
 
 	{
+		apiV1.GET("/errors", controller.ListErrors)
 		//apiV1.POST("/user/get_one", controller.GetUser)
 		//apiV1.POST("/student/get_one", controller.GetStudent)
 	}
 
+	admin := r.Group("/admin")
+	admin.Use(middleware.AdminAuth(adminToken))
+
+	{
+		admin.GET("/log/level", controller.GetLogLevel)
+		admin.PUT("/log/level", controller.SetLogLevel)
+		admin.DELETE("/log/level", controller.ResetLogLevel)
+		admin.GET("/readyz", controller.ReadyzDetail)
+		// expvar, includes the database pool stats
+		admin.GET("/debug/vars", gin.WrapH(expvar.Handler()))
+	}
+
+	r.GET("/healthz", controller.Healthz)
+	r.GET("/readyz", controller.Readyz)
+
 	r.GET("/ping", func(c *gin.Context) {
 		logger.Info(c.Request.Context(), "ping")
 		rest.R.Success(c, "pong")
@@ -0,0 +1,100 @@
+package router
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/codes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"top-ping/pkg/baseerr"
+	"top-ping/pkg/logger"
+	"top-ping/pkg/ratelimit"
+	"top-ping/pkg/tracing/tracingtest"
+	"top-ping/pkg/utils"
+)
+
+var logDir string
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "router")
+	if err != nil {
+		panic(err)
+	}
+	logDir = dir
+
+	disabled := false
+	logger.Init(utils.TestProfile, &logger.Config{Level: "error", Dir: logDir, Stdout: logger.OutputConfig{Enabled: &disabled}})
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestPanicResponse(t *testing.T) {
+	exporter := tracingtest.Install(t)
+	r := Router(utils.TestProfile, ratelimit.NewLimiter(&ratelimit.Config{}), "")
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/panic", nil))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", w.Code)
+	}
+
+	var logged *responseLog
+	for _, entry := range readAccessLog(t) {
+		if entry.Msg == "ResponseLog" && entry.Path == "/panic" {
+			logged = &entry
+		}
+	}
+	if logged == nil {
+		data, _ := os.ReadFile(filepath.Join(logDir, "access.log"))
+		t.Fatalf("no ResponseLog of the panic in %s", data)
+	}
+	if logged.Status != http.StatusInternalServerError || logged.TraceID == "" {
+		t.Errorf("ResponseLog status %d, trace %q, want 500 with a trace id", logged.Status, logged.TraceID)
+	}
+	var body struct {
+		Code int `json:"code"`
+	}
+	if err := json.Unmarshal([]byte(logged.Response), &body); err != nil || body.Code != baseerr.ErrInternalServer.Code() {
+		t.Errorf("ResponseLog response %q, want the ErrInternalServer body", logged.Response)
+	}
+
+	spans := exporter.GetSpans()
+	if len(spans) != 1 || spans[0].Status.Code != codes.Error {
+		t.Errorf("spans %v, want one with an error status", spans)
+	}
+}
+
+type responseLog struct {
+	Msg      string `json:"msg"`
+	Path     string `json:"Path"`
+	Status   int    `json:"Status"`
+	Response string `json:"Response"`
+	TraceID  string `json:"TraceID"`
+}
+
+func readAccessLog(t *testing.T) []responseLog {
+	t.Helper()
+	file, err := os.Open(filepath.Join(logDir, "access.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	var entries []responseLog
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry responseLog
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err == nil {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
@@ -1,6 +1,7 @@
 package baseerr
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 )
@@ -8,62 +9,111 @@ import (
 var (
 	// 预定义错误
 	// Common errors
-	Success               = NewError(0, "Success")
-	ErrInternalServer     = NewError(10001, "Internal server error")
-	ErrBind               = NewError(10002, "Bind request error")
-	ErrInvalidParam       = NewError(10003, "Invalid params")
-	ErrSignParam          = NewError(10004, "Invalid sign")
-	ErrValidation         = NewError(10005, "Validation failed")
-	ErrDatabase           = NewError(10006, "Database error")
-	ErrToken              = NewError(10007, "Gen token error")
-	ErrInvalidToken       = NewError(10108, "Invalid token")
-	ErrTokenTimeout       = NewError(10109, "Token timeout")
-	ErrTooManyRequests    = NewError(10110, "Too many request")
-	ErrInvalidTransaction = NewError(10111, "Invalid transaction")
-	ErrEncrypt            = NewError(10112, "Encrypting the user password error")
-	ErrLimitExceed        = NewError(10113, "Beyond limit")
-	ErrServiceUnavailable = NewError(10114, "Service Unavailable")
+	Success               = NewError(0, http.StatusOK, Messages{LangEN: "Success", LangZhCN: "成功"})
+	ErrInternalServer     = NewError(10001, http.StatusInternalServerError, Messages{LangEN: "Internal server error", LangZhCN: "服务器内部错误"})
+	ErrBind               = NewError(10002, http.StatusBadRequest, Messages{LangEN: "Bind request error", LangZhCN: "请求参数绑定错误"})
+	ErrInvalidParam       = NewError(10003, http.StatusBadRequest, Messages{LangEN: "Invalid params", LangZhCN: "参数无效"})
+	ErrSignParam          = NewError(10004, http.StatusBadRequest, Messages{LangEN: "Invalid sign", LangZhCN: "签名无效"})
+	ErrValidation         = NewError(10005, http.StatusUnprocessableEntity, Messages{LangEN: "Validation failed", LangZhCN: "参数校验失败"})
+	ErrDatabase           = NewError(10006, http.StatusInternalServerError, Messages{LangEN: "Database error", LangZhCN: "数据库错误"})
+	ErrToken              = NewError(10007, http.StatusUnauthorized, Messages{LangEN: "Gen token error", LangZhCN: "生成令牌错误"})
+	ErrInvalidToken       = NewError(10108, http.StatusUnauthorized, Messages{LangEN: "Invalid token", LangZhCN: "令牌无效"})
+	ErrTokenTimeout       = NewError(10109, http.StatusUnauthorized, Messages{LangEN: "Token timeout", LangZhCN: "令牌已过期"})
+	ErrTooManyRequests    = NewError(10110, http.StatusTooManyRequests, Messages{LangEN: "Too many request", LangZhCN: "请求过于频繁"})
+	ErrInvalidTransaction = NewError(10111, http.StatusInternalServerError, Messages{LangEN: "Invalid transaction", LangZhCN: "事务无效"})
+	ErrEncrypt            = NewError(10112, http.StatusInternalServerError, Messages{LangEN: "Encrypting the user password error", LangZhCN: "用户密码加密错误"})
+	ErrLimitExceed        = NewError(10113, http.StatusTooManyRequests, Messages{LangEN: "Beyond limit", LangZhCN: "超出限制"})
+	ErrServiceUnavailable = NewError(10114, http.StatusServiceUnavailable, Messages{LangEN: "Service Unavailable", LangZhCN: "服务不可用"})
+	ErrNotFound           = NewError(10115, http.StatusNotFound, Messages{LangEN: "Record not found", LangZhCN: "记录不存在"})
 )
 
 type Error struct {
-	code    int      `json:"code"`
-	msg     string   `json:"msg"`
-	details []string `json:"details"`
+	code    int
+	args    []interface{}
+	details []interface{}
+	// cause 只用于日志，不返回给客户端
+	cause error
 }
 
-var codes = map[int]struct{}{}
+// FieldError detail of one invalid request field
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
 
-func NewError(code int, msg string) *Error {
-	if _, ok := codes[code]; ok {
-		panic(fmt.Sprintf("code %d is exsit, please change one", code))
-	}
-	codes[code] = struct{}{}
-	return &Error{code: code, msg: msg}
+// NewError register the code with its http status and messages, messages must contain DefaultLang
+func NewError(code int, status int, messages Messages) *Error {
+	register(code, status, messages)
+	return &Error{code: code}
 }
 
-func (e Error) Error() string {
+func (e *Error) Error() string {
+	if e.cause != nil {
+		return fmt.Sprintf("code：%d, msg:：%s, cause: %v", e.Code(), e.Msg(), e.cause)
+	}
 	return fmt.Sprintf("code：%d, msg:：%s", e.Code(), e.Msg())
 }
 
+// Is errors with the same code are equal, whatever details or cause they carry
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	if !ok || e == nil || t == nil {
+		// e.g. a typed nil *Error target
+		return false
+	}
+	return e.code == t.code
+}
+
+func (e *Error) Unwrap() error {
+	return e.cause
+}
+
+func (e *Error) Cause() error {
+	return e.cause
+}
+
+// Wrap keep the underlying error for logging, clients still see the code and msg only
+func (e *Error) Wrap(cause error) *Error {
+	newError := *e
+	newError.cause = cause
+
+	return &newError
+}
+
 func (e *Error) Code() int {
 	return e.code
 }
 
 func (e *Error) Msg() string {
-	return e.msg
+	return e.MsgIn(DefaultLang)
+}
+
+// MsgIn the message translated to lang, falling back to DefaultLang
+func (e *Error) MsgIn(lang string) string {
+	return format(message(e.code, lang), e.args)
 }
 
-func (e *Error) Msgf(args []interface{}) string {
-	return fmt.Sprintf(e.msg, args...)
+// Msgf format the default message with args
+func (e *Error) Msgf(args ...interface{}) string {
+	return format(message(e.code, DefaultLang), args)
 }
 
-func (e *Error) Details() []string {
+// WithArgs bind args of a parameterized message, e.g. "Beyond limit of %d"
+func (e *Error) WithArgs(args ...interface{}) *Error {
+	newError := *e
+	newError.args = args
+
+	return &newError
+}
+
+func (e *Error) Details() []interface{} {
 	return e.details
 }
 
-func (e *Error) WithDetails(details ...string) *Error {
+func (e *Error) WithDetails(details ...interface{}) *Error {
 	newError := *e
-	newError.details = []string{}
+	newError.details = []interface{}{}
 	for _, d := range details {
 		newError.details = append(newError.details, d)
 	}
@@ -73,23 +123,8 @@ func (e *Error) WithDetails(details ...string) *Error {
 
 // StatusCode trans err code to http status code
 func (e *Error) StatusCode() int {
-	switch e.Code() {
-	case Success.Code():
-		return http.StatusOK
-	case ErrInternalServer.Code():
-		return http.StatusInternalServerError
-	case ErrInvalidParam.Code():
-		return http.StatusBadRequest
-	case ErrToken.Code():
-		fallthrough
-	case ErrInvalidToken.Code():
-		fallthrough
-	case ErrTokenTimeout.Code():
-		return http.StatusUnauthorized
-	case ErrTooManyRequests.Code():
-		return http.StatusTooManyRequests
-	case ErrServiceUnavailable.Code():
-		return http.StatusServiceUnavailable
+	if d, ok := registry[e.code]; ok {
+		return d.Status
 	}
 
 	return http.StatusOK
@@ -106,18 +141,24 @@ func (err *Err) Error() string {
 	return fmt.Sprintf("Err - code: %d, message: %s, error: %s", err.Code, err.Message, err.Err)
 }
 
+func (err *Err) Unwrap() error {
+	return err.Err
+}
+
 // DecodeErr 对错误进行解码，返回错误code和错误提示
 func DecodeErr(err error) (int, string) {
 	if err == nil {
-		return Success.code, Success.msg
+		return Success.code, Success.Msg()
+	}
+
+	var codeErr *Err
+	if errors.As(err, &codeErr) {
+		return codeErr.Code, codeErr.Message
 	}
 
-	switch typed := err.(type) {
-	case *Err:
-		return typed.Code, typed.Message
-	case *Error:
-		return typed.code, typed.msg
-	default:
+	var baseErr *Error
+	if errors.As(err, &baseErr) {
+		return baseErr.code, baseErr.Msg()
 	}
 
 	return ErrInternalServer.Code(), err.Error()
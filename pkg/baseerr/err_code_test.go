@@ -0,0 +1,101 @@
+package baseerr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		err    *Error
+		status int
+	}{
+		{Success, http.StatusOK},
+		{ErrInvalidParam, http.StatusBadRequest},
+		{ErrSignParam, http.StatusBadRequest},
+		{ErrValidation, http.StatusUnprocessableEntity},
+		{ErrInvalidToken, http.StatusUnauthorized},
+		{ErrNotFound, http.StatusNotFound},
+		{ErrTooManyRequests, http.StatusTooManyRequests},
+		{ErrLimitExceed, http.StatusTooManyRequests},
+		{ErrDatabase, http.StatusInternalServerError},
+		{ErrEncrypt, http.StatusInternalServerError},
+		{ErrInvalidTransaction, http.StatusInternalServerError},
+		{ErrServiceUnavailable, http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		if got := tt.err.StatusCode(); got != tt.status {
+			t.Errorf("%d %s: StatusCode() = %d, want %d", tt.err.Code(), tt.err.Msg(), got, tt.status)
+		}
+		// wrapped and detailed copies keep the status
+		if got := tt.err.Wrap(nil).WithDetails("x").StatusCode(); got != tt.status {
+			t.Errorf("%d %s: StatusCode() of a copy = %d, want %d", tt.err.Code(), tt.err.Msg(), got, tt.status)
+		}
+	}
+}
+
+func TestWrapAndIs(t *testing.T) {
+	cause := errors.New("connection refused")
+	var nilErr *Error
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		is     bool
+	}{
+		{name: "same code", err: ErrNotFound, target: ErrNotFound, is: true},
+		{name: "other code", err: ErrNotFound, target: ErrDatabase},
+		{name: "copy with details", err: ErrValidation.WithDetails(FieldError{Field: "name"}), target: ErrValidation, is: true},
+		{name: "wrapped", err: ErrDatabase.Wrap(cause), target: ErrDatabase, is: true},
+		{name: "cause of a wrapped", err: ErrDatabase.Wrap(cause), target: cause, is: true},
+		{name: "through fmt.Errorf", err: fmt.Errorf("get user: %w", ErrNotFound), target: ErrNotFound, is: true},
+		{name: "cause through fmt.Errorf", err: fmt.Errorf("get user: %w", ErrDatabase.Wrap(cause)), target: cause, is: true},
+		{name: "inner of nested", err: ErrInvalidTransaction.Wrap(fmt.Errorf("tx: %w", ErrNotFound)), target: ErrNotFound, is: true},
+		{name: "through Err", err: &Err{Code: 1, Err: ErrNotFound}, target: ErrNotFound, is: true},
+		{name: "typed nil target", err: ErrNotFound, target: nilErr},
+		{name: "other error type", err: ErrNotFound, target: cause},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.is {
+				t.Errorf("errors.Is(%v, %v) = %v, want %v", tt.err, tt.target, got, tt.is)
+			}
+		})
+	}
+
+	wrapped := ErrDatabase.Wrap(cause)
+	if wrapped.Unwrap() != cause || wrapped.Cause() != cause {
+		t.Errorf("Unwrap() = %v, want the cause", wrapped.Unwrap())
+	}
+	if ErrDatabase.Unwrap() != nil {
+		t.Error("Wrap() changed the predefined error")
+	}
+}
+
+func TestDecodeErr(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code int
+		msg  string
+	}{
+		{name: "nil", err: nil, code: Success.Code(), msg: Success.Msg()},
+		{name: "base error", err: ErrNotFound, code: ErrNotFound.Code(), msg: "Record not found"},
+		{name: "wrapped base error", err: ErrDatabase.Wrap(errors.New("timeout")), code: ErrDatabase.Code(), msg: "Database error"},
+		{name: "through fmt.Errorf", err: fmt.Errorf("get user: %w", ErrNotFound), code: ErrNotFound.Code(), msg: "Record not found"},
+		{name: "Err", err: fmt.Errorf("call: %w", &Err{Code: 20001, Message: "upstream failed"}), code: 20001, msg: "upstream failed"},
+		{name: "other error", err: errors.New("boom"), code: ErrInternalServer.Code(), msg: "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code, msg := DecodeErr(tt.err); code != tt.code || msg != tt.msg {
+				t.Errorf("DecodeErr() = %d, %q, want %d, %q", code, msg, tt.code, tt.msg)
+			}
+		})
+	}
+}
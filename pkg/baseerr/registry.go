@@ -0,0 +1,132 @@
+package baseerr
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	LangEN      = "en"
+	LangZhCN    = "zh-CN"
+	DefaultLang = LangEN
+)
+
+// Languages supported languages, every registered code should translate all of them
+var Languages = []string{LangEN, LangZhCN}
+
+// Messages message of each language, keyed by language tag
+type Messages map[string]string
+
+// Definition registry entry of an error code
+type Definition struct {
+	Code     int      `json:"code"`
+	Status   int      `json:"status"`
+	Message  string   `json:"message"`
+	Messages Messages `json:"messages"`
+}
+
+var registry = map[int]*Definition{}
+
+func register(code int, status int, messages Messages) {
+	if _, ok := registry[code]; ok {
+		panic(fmt.Sprintf("code %d is exsit, please change one", code))
+	}
+	if _, ok := messages[DefaultLang]; !ok {
+		panic(fmt.Sprintf("code %d has no %s message", code, DefaultLang))
+	}
+
+	registry[code] = &Definition{
+		Code:     code,
+		Status:   status,
+		Message:  messages[DefaultLang],
+		Messages: messages,
+	}
+}
+
+// Definitions all registered codes ordered by code, Message is translated to lang
+func Definitions(lang string) []Definition {
+	definitions := make([]Definition, 0, len(registry))
+	for _, d := range registry {
+		definition := *d
+		definition.Message = message(d.Code, lang)
+		definitions = append(definitions, definition)
+	}
+
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Code < definitions[j].Code
+	})
+
+	return definitions
+}
+
+// MatchLang pick the best supported language of an Accept-Language header
+func MatchLang(acceptLanguage string) string {
+	best, bestQ := DefaultLang, 0.0
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, q := parseLangRange(part)
+		if tag == "" || q <= bestQ {
+			continue
+		}
+		if lang, ok := matchSupported(tag); ok {
+			best, bestQ = lang, q
+		}
+	}
+
+	return best
+}
+
+func parseLangRange(part string) (string, float64) {
+	fields := strings.Split(strings.TrimSpace(part), ";")
+	tag := strings.TrimSpace(fields[0])
+	q := 1.0
+	for _, param := range fields[1:] {
+		param = strings.TrimSpace(param)
+		if strings.HasPrefix(param, "q=") {
+			if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+				q = v
+			}
+		}
+	}
+
+	return tag, q
+}
+
+// matchSupported match the full tag first, then the primary subtag, e.g. zh-Hans -> zh-CN
+func matchSupported(tag string) (string, bool) {
+	for _, lang := range Languages {
+		if strings.EqualFold(lang, tag) {
+			return lang, true
+		}
+	}
+
+	primary := strings.SplitN(tag, "-", 2)[0]
+	for _, lang := range Languages {
+		if strings.EqualFold(strings.SplitN(lang, "-", 2)[0], primary) {
+			return lang, true
+		}
+	}
+
+	return "", false
+}
+
+func message(code int, lang string) string {
+	d, ok := registry[code]
+	if !ok {
+		return ""
+	}
+	if msg, ok := d.Messages[lang]; ok {
+		return msg
+	}
+
+	return d.Message
+}
+
+func format(msg string, args []interface{}) string {
+	if len(args) == 0 {
+		return msg
+	}
+
+	return fmt.Sprintf(msg, args...)
+}
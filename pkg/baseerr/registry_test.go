@@ -0,0 +1,107 @@
+package baseerr
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestMatchLang(t *testing.T) {
+	tests := []struct {
+		acceptLanguage string
+		want           string
+	}{
+		{"", LangEN},
+		{"zh-CN", LangZhCN},
+		{"ZH-cn", LangZhCN},
+		{"zh-TW", LangZhCN},
+		{"zh", LangZhCN},
+		{"zh-Hans-CN", LangZhCN},
+		{"fr-FR", LangEN},
+		{"*", LangEN},
+		{"fr, *;q=0.5", LangEN},
+		{"en;q=0.8, zh-CN", LangZhCN},
+		{"zh-CN;q=0.5, en;q=0.9", LangEN},
+		{"fr;q=1, zh-TW;q=0.7, en;q=0.3", LangZhCN},
+		{"zh-CN;q=0, en-US", LangEN},
+		{"zh-CN;q=x", LangZhCN},
+		{" en-GB ; q=0.9 , zh ; q=0.8", LangEN},
+	}
+
+	for _, tt := range tests {
+		if got := MatchLang(tt.acceptLanguage); got != tt.want {
+			t.Errorf("MatchLang(%q) = %s, want %s", tt.acceptLanguage, got, tt.want)
+		}
+	}
+}
+
+func TestDefinitions(t *testing.T) {
+	tests := []struct {
+		lang string
+		want string
+	}{
+		{LangEN, "Record not found"},
+		{LangZhCN, "记录不存在"},
+		{"fr", "Record not found"},
+	}
+
+	for _, tt := range tests {
+		definitions := Definitions(tt.lang)
+		if len(definitions) != len(registry) {
+			t.Fatalf("Definitions(%s) has %d codes, want %d", tt.lang, len(definitions), len(registry))
+		}
+		if !sort.SliceIsSorted(definitions, func(i, j int) bool { return definitions[i].Code < definitions[j].Code }) {
+			t.Errorf("Definitions(%s) not ordered by code", tt.lang)
+		}
+
+		for _, d := range definitions {
+			if d.Code != ErrNotFound.Code() {
+				continue
+			}
+			if d.Message != tt.want || d.Status != ErrNotFound.StatusCode() || len(d.Messages) != len(Languages) {
+				t.Errorf("Definitions(%s) of ErrNotFound = %+v, want message %q", tt.lang, d, tt.want)
+			}
+		}
+	}
+
+	// callers get copies
+	Definitions(LangZhCN)[0].Message = "changed"
+	if Definitions(LangEN)[0].Message == "changed" {
+		t.Error("Definitions() returned the registry entries")
+	}
+}
+
+func TestRegister(t *testing.T) {
+	const code = 99901
+	t.Cleanup(func() {
+		delete(registry, code)
+		delete(registry, code+1)
+	})
+
+	tests := []struct {
+		name     string
+		code     int
+		messages Messages
+		panics   bool
+	}{
+		{name: "new code", code: code, messages: Messages{LangEN: "Test"}},
+		{name: "duplicate code", code: code, messages: Messages{LangEN: "Test again"}, panics: true},
+		{name: "no default language", code: code + 1, messages: Messages{LangZhCN: "测试"}, panics: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			defer func() {
+				if r := recover(); (r != nil) != tt.panics {
+					t.Errorf("NewError() panic = %v, want panic %v", r, tt.panics)
+				}
+			}()
+			NewError(tt.code, 418, tt.messages)
+		})
+	}
+	if _, ok := registry[code+1]; ok {
+		t.Error("code without a default message registered")
+	}
+	if registry[code].Message != "Test" {
+		t.Errorf("registered message %q, want the first one", registry[code].Message)
+	}
+}
@@ -0,0 +1,25 @@
+package clientip
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Config struct {
+	// TrustedProxies CIDRs or IPs of the proxies in front of us, forwarding headers of other callers are ignored.
+	// The peer address is the client ip when empty.
+	TrustedProxies []string `mapstructure:"trustedProxies"`
+	// Header the one forwarding header the trusted proxies set, X-Forwarded-For when empty.
+	// Forwarded is parsed as RFC 7239, others as a comma separated list, e.g. X-Real-Ip or CF-Connecting-IP.
+	Header string `mapstructure:"header"`
+}
+
+func (c *Config) Validate() error {
+	if _, err := parseCIDRs(c.TrustedProxies); err != nil {
+		return fmt.Errorf("clientIp.trustedProxies: %v", err)
+	}
+	if strings.ContainsAny(c.Header, " :\t") {
+		return fmt.Errorf("clientIp.header: invalid header name %q", c.Header)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package clientip
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+)
+
+// Resolver resolve the client ip of a request, only trusting forwarding headers set by trusted proxies
+type Resolver struct {
+	trusted []*net.IPNet
+	header  string
+}
+
+const (
+	defaultHeader   = "X-Forwarded-For"
+	forwardedHeader = "Forwarded"
+)
+
+var current atomic.Pointer[Resolver]
+
+func init() {
+	current.Store(&Resolver{header: defaultHeader})
+}
+
+func NewResolver(config *Config) (*Resolver, error) {
+	trusted, err := parseCIDRs(config.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	header := http.CanonicalHeaderKey(config.Header)
+	if header == "" {
+		header = defaultHeader
+	}
+	return &Resolver{trusted: trusted, header: header}, nil
+}
+
+// SetDefault the resolver used by FromRequest
+func SetDefault(r *Resolver) {
+	current.Store(r)
+}
+
+// FromRequest the client ip of req by the default resolver
+func FromRequest(req *http.Request) string {
+	return current.Load().Resolve(req)
+}
+
+// Resolve walk the forwarding chain of the configured header right to left from the peer,
+// the first untrusted hop is the client. Other forwarding headers are ignored, clients can set them too.
+func (r *Resolver) Resolve(req *http.Request) string {
+	ip := parseIP(req.RemoteAddr)
+	if ip == nil {
+		return req.RemoteAddr
+	}
+	if !r.isTrusted(ip) {
+		return ip.String()
+	}
+
+	var chain []string
+	if r.header == forwardedHeader {
+		chain = forwardedFor(req.Header)
+	} else {
+		chain = splitList(req.Header.Values(r.header))
+	}
+
+	for i := len(chain) - 1; i >= 0; i-- {
+		hop := parseIP(chain[i])
+		if hop == nil {
+			// an obfuscated or garbled hop, nothing left of it can be trusted
+			break
+		}
+		ip = hop
+		if !r.isTrusted(hop) {
+			break
+		}
+	}
+	return ip.String()
+}
+
+func (r *Resolver) isTrusted(ip net.IP) bool {
+	for _, network := range r.trusted {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// forwardedFor the for= values of the Forwarded headers in order, e.g. for=192.0.2.60;proto=http, for="[2001:db8::1]:80"
+func forwardedFor(header http.Header) []string {
+	var result []string
+	for _, element := range splitList(header.Values("Forwarded")) {
+		for _, pair := range strings.Split(element, ";") {
+			name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+			if ok && strings.EqualFold(name, "for") {
+				result = append(result, strings.Trim(value, `"`))
+			}
+		}
+	}
+	return result
+}
+
+func splitList(values []string) []string {
+	var result []string
+	for _, value := range values {
+		for _, item := range strings.Split(value, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				result = append(result, item)
+			}
+		}
+	}
+	return result
+}
+
+// parseIP an ip with an optional port, ipv6 in brackets when it has one
+func parseIP(s string) net.IP {
+	s = strings.TrimSpace(s)
+	if host, _, err := net.SplitHostPort(s); err == nil {
+		s = host
+	}
+	return net.ParseIP(strings.Trim(s, "[]"))
+}
+
+func parseCIDRs(values []string) ([]*net.IPNet, error) {
+	var result []*net.IPNet
+	for _, value := range values {
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid ip %q", value)
+			}
+			bits := 8 * net.IPv4len
+			if ip.To4() == nil {
+				bits = 8 * net.IPv6len
+			}
+			value = fmt.Sprintf("%s/%d", value, bits)
+		}
+
+		_, network, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q", value)
+		}
+		result = append(result, network)
+	}
+	return result, nil
+}
@@ -0,0 +1,152 @@
+package clientip
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestResolve(t *testing.T) {
+	proxies := []string{"10.0.0.0/8", "192.168.1.1", "fd00::/8"}
+	tests := []struct {
+		name       string
+		trusted    []string
+		header     string
+		remoteAddr string
+		headers    map[string][]string
+		want       string
+	}{
+		{
+			name:       "no trusted proxies",
+			remoteAddr: "203.0.113.7:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.1.1.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "untrusted peer spoofing",
+			trusted:    proxies,
+			remoteAddr: "203.0.113.7:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.1.1.1"}},
+			want:       "203.0.113.7",
+		},
+		{
+			name:       "trusted peer",
+			trusted:    proxies,
+			remoteAddr: "10.1.2.3:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.9"}},
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "spoofed left hop",
+			trusted:    proxies,
+			remoteAddr: "10.1.2.3:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.1.1.1, 198.51.100.9, 192.168.1.1"}},
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "several header lines",
+			trusted:    proxies,
+			remoteAddr: "10.1.2.3:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"1.1.1.1", "198.51.100.9, 10.9.9.9"}},
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "all hops trusted",
+			trusted:    proxies,
+			remoteAddr: "10.1.2.3:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.2, 10.0.0.1"}},
+			want:       "10.0.0.2",
+		},
+		{
+			name:       "garbled hop stops the walk",
+			trusted:    proxies,
+			remoteAddr: "10.1.2.3:5000",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.9, unknown, 10.0.0.1"}},
+			want:       "10.0.0.1",
+		},
+		{
+			name:       "no header",
+			trusted:    proxies,
+			remoteAddr: "10.1.2.3:5000",
+			want:       "10.1.2.3",
+		},
+		{
+			name:       "other headers are ignored",
+			trusted:    proxies,
+			remoteAddr: "10.1.2.3:5000",
+			headers:    map[string][]string{"X-Real-Ip": {"1.1.1.1"}, "Forwarded": {"for=2.2.2.2"}},
+			want:       "10.1.2.3",
+		},
+		{
+			name:       "configured header",
+			trusted:    proxies,
+			header:     "x-real-ip",
+			remoteAddr: "10.1.2.3:5000",
+			headers:    map[string][]string{"X-Real-Ip": {"198.51.100.9"}, "X-Forwarded-For": {"1.1.1.1"}},
+			want:       "198.51.100.9",
+		},
+		{
+			name:       "forwarded",
+			trusted:    proxies,
+			header:     "Forwarded",
+			remoteAddr: "10.1.2.3:5000",
+			headers:    map[string][]string{"Forwarded": {`for=1.1.1.1, for="[2001:db8::1]:4711";proto=https, For=10.0.0.1`}},
+			want:       "2001:db8::1",
+		},
+		{
+			name:       "ipv6 peer",
+			trusted:    proxies,
+			remoteAddr: "[fd00::1]:443",
+			headers:    map[string][]string{"X-Forwarded-For": {"2001:db8::2"}},
+			want:       "2001:db8::2",
+		},
+		{
+			name:       "unparseable peer",
+			trusted:    proxies,
+			remoteAddr: "@unix",
+			want:       "@unix",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := NewResolver(&Config{TrustedProxies: tt.trusted, Header: tt.header})
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &http.Request{RemoteAddr: tt.remoteAddr, Header: http.Header(tt.headers)}
+			if got := r.Resolve(req); got != tt.want {
+				t.Errorf("Resolve() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		err    string
+	}{
+		{name: "empty", config: Config{}},
+		{name: "ips and cidrs", config: Config{TrustedProxies: []string{"10.0.0.1", "::1", "172.16.0.0/12"}, Header: "X-Real-Ip"}},
+		{name: "invalid ip", config: Config{TrustedProxies: []string{"10.0.0"}}, err: `clientIp.trustedProxies: invalid ip "10.0.0"`},
+		{name: "invalid cidr", config: Config{TrustedProxies: []string{"10.0.0.0/33"}}, err: `clientIp.trustedProxies: invalid cidr "10.0.0.0/33"`},
+		{name: "invalid header", config: Config{Header: "X-Forwarded-For: 1.1.1.1"}, err: "clientIp.header: invalid header name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
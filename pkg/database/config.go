@@ -1,10 +1,163 @@
 package database
 
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
 type DatasourceConfig struct {
+	// DriverName mysql, postgres or sqlite, mysql when empty
 	DriverName string `mapstructure:"driverName"`
 	Addr       string `mapstructure:"addr"`
-	Database   string `mapstructure:"database"`
-	User       string `mapstructure:"user"`
-	Password   string `mapstructure:"password"`
-	Charset    string `mapstructure:"charset"`
+	// Port the default port of the driver when 0
+	Port     int    `mapstructure:"port"`
+	Database string `mapstructure:"database"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+	Charset  string `mapstructure:"charset"`
+	// TLS the tls param of mysql or the sslmode of postgres
+	TLS string `mapstructure:"tls"`
+	// TimeZone e.g. Asia/Shanghai, Local when empty
+	TimeZone string `mapstructure:"timeZone"`
+	// Params extra driver params appended to the dsn, key=value e.g. parseTime=true.
+	// A list and not a map, viper would lowercase the keys.
+	Params []string `mapstructure:"params"`
+
+	// ConnectTimeout dial timeout, 10s when 0. ReadTimeout and WriteTimeout are supported by mysql only
+	ConnectTimeout time.Duration `mapstructure:"connectTimeout"`
+	ReadTimeout    time.Duration `mapstructure:"readTimeout"`
+	WriteTimeout   time.Duration `mapstructure:"writeTimeout"`
+
+	// StartupTimeout how long Init keeps retrying the connection, 30s when 0
+	StartupTimeout time.Duration `mapstructure:"startupTimeout"`
+
+	Pool PoolConfig `mapstructure:"pool"`
+	// Replicas serve reads, writes and transactions always go to the primary
+	Replicas []ReplicaConfig `mapstructure:"replicas"`
+}
+
+// PoolConfig zero values keep the defaults: 10 idle, 100 open, 1h lifetime
+type PoolConfig struct {
+	MaxIdleConns    int           `mapstructure:"maxIdleConns"`
+	MaxOpenConns    int           `mapstructure:"maxOpenConns"`
+	ConnMaxLifetime time.Duration `mapstructure:"connMaxLifetime"`
+	ConnMaxIdleTime time.Duration `mapstructure:"connMaxIdleTime"`
+}
+
+// ReplicaConfig empty fields are taken from the primary
+type ReplicaConfig struct {
+	Addr     string `mapstructure:"addr"`
+	Port     int    `mapstructure:"port"`
+	User     string `mapstructure:"user"`
+	Password string `mapstructure:"password"`
+}
+
+func (c *DatasourceConfig) Driver() string {
+	if c.DriverName == "" {
+		return MysqlDriver
+	}
+	return c.DriverName
+}
+
+func (c *DatasourceConfig) PortOr(defaultPort int) int {
+	if c.Port == 0 {
+		return defaultPort
+	}
+	return c.Port
+}
+
+func (c *DatasourceConfig) ConnectTimeoutOr(defaultTimeout time.Duration) time.Duration {
+	if c.ConnectTimeout <= 0 {
+		return defaultTimeout
+	}
+	return c.ConnectTimeout
+}
+
+func (c *DatasourceConfig) StartupTimeoutOr(defaultTimeout time.Duration) time.Duration {
+	if c.StartupTimeout <= 0 {
+		return defaultTimeout
+	}
+	return c.StartupTimeout
+}
+
+func (c *DatasourceConfig) Location() string {
+	if c.TimeZone == "" {
+		return "Local"
+	}
+	return c.TimeZone
+}
+
+// extraParams Params by key, later ones win
+func (c *DatasourceConfig) extraParams() map[string]string {
+	params := make(map[string]string, len(c.Params))
+	for _, param := range c.Params {
+		if k, v, ok := strings.Cut(param, "="); ok && k != "" {
+			params[k] = v
+		}
+	}
+	return params
+}
+
+// replica the datasource of a replica, based on the primary
+func (c *DatasourceConfig) replica(r ReplicaConfig) *DatasourceConfig {
+	replica := *c
+	replica.Replicas = nil
+	replica.Addr = r.Addr
+	if r.Port != 0 {
+		replica.Port = r.Port
+	}
+	if r.User != "" {
+		replica.User = r.User
+	}
+	if r.Password != "" {
+		replica.Password = r.Password
+	}
+
+	return &replica
+}
+
+func (c *DatasourceConfig) Validate() error {
+	for i, param := range c.Params {
+		if k, _, ok := strings.Cut(param, "="); !ok || k == "" {
+			return fmt.Errorf("mysql.params[%d]: must be key=value, got %q", i, param)
+		}
+	}
+
+	switch c.Driver() {
+	case MysqlDriver, PostgresDriver:
+	case SqliteDriver:
+		if c.Database == "" {
+			return fmt.Errorf("mysql.database: the db file path is required by sqlite")
+		}
+		if len(c.Replicas) > 0 {
+			return fmt.Errorf("mysql.replicas: are not supported by sqlite")
+		}
+		return nil
+	default:
+		return fmt.Errorf("mysql.driverName: must be %s, %s or %s, got %q", MysqlDriver, PostgresDriver, SqliteDriver, c.DriverName)
+	}
+
+	if c.Addr == "" {
+		return fmt.Errorf("mysql.addr: is required by %s", c.Driver())
+	}
+	if c.Database == "" {
+		return fmt.Errorf("mysql.database: is required by %s", c.Driver())
+	}
+	if c.User == "" {
+		return fmt.Errorf("mysql.user: is required by %s", c.Driver())
+	}
+	if c.Port < 0 || c.Port > 65535 {
+		return fmt.Errorf("mysql.port: must be between 0 and 65535, got %d", c.Port)
+	}
+	if c.Pool.MaxIdleConns < 0 || c.Pool.MaxOpenConns < 0 {
+		return fmt.Errorf("mysql.pool: connection counts must not be negative")
+	}
+	for i, replica := range c.Replicas {
+		if replica.Addr == "" {
+			return fmt.Errorf("mysql.replicas[%d].addr: is required", i)
+		}
+	}
+
+	return nil
 }
@@ -0,0 +1,317 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"expvar"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+	"gorm.io/plugin/dbresolver"
+	"sync"
+	"time"
+	"top-ping/pkg/logger"
+)
+
+const (
+	MysqlDriver    = "mysql"
+	PostgresDriver = "postgres"
+	SqliteDriver   = "sqlite"
+
+	defaultMaxIdleConns    = 10
+	defaultMaxOpenConns    = 100
+	defaultConnMaxLifetime = time.Hour
+
+	defaultConnectTimeout = 10 * time.Second
+	defaultStartupTimeout = 30 * time.Second
+	minRetryDelay         = 500 * time.Millisecond
+	maxRetryDelay         = 10 * time.Second
+)
+
+var (
+	// defaultDB nil until connected, read it with Get
+	defaultDB *gorm.DB
+	dbMu      sync.RWMutex
+
+	poolsMu sync.RWMutex
+	// pools connection pools of defaultDB by name, primary and replica-N, for the pool stats.
+	// Registered once it is connected, a connect given up on never shows up
+	pools = map[string]*sql.DB{}
+)
+
+func init() {
+	expvar.Publish("database", expvar.Func(func() interface{} {
+		return Stats()
+	}))
+}
+
+// Init connect to the database, retrying with backoff until StartupTimeout or ctx is done
+func Init(ctx context.Context, dataSource *DatasourceConfig) error {
+	ctx, cancel := context.WithTimeout(ctx, dataSource.StartupTimeoutOr(defaultStartupTimeout))
+	defer cancel()
+
+	delay := minRetryDelay
+	for attempt := 1; ; attempt++ {
+		db, dbPools, err := connect(ctx, dataSource)
+		if err == nil {
+			setDB(db)
+			setPools(dbPools)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return fmt.Errorf("connect %s: gave up after %d attempts: %w", dataSource.Driver(), attempt, err)
+		}
+		logger.Warnf(ctx, "gorm %s connect attempt %d failed, retrying in %s: %v", dataSource.Driver(), attempt, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("connect %s: gave up after %d attempts: %w", dataSource.Driver(), attempt, err)
+		case <-time.After(delay):
+		}
+
+		delay *= 2
+		if delay > maxRetryDelay {
+			delay = maxRetryDelay
+		}
+	}
+}
+
+// connect newDB returning when ctx is done, even if the driver hangs in a read that has no timeout
+func connect(ctx context.Context, dataSource *DatasourceConfig) (*gorm.DB, map[string]*sql.DB, error) {
+	type result struct {
+		db    *gorm.DB
+		pools map[string]*sql.DB
+		err   error
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		db, dbPools, err := newDB(ctx, dataSource)
+		done <- result{db, dbPools, err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.db, r.pools, r.err
+	case <-ctx.Done():
+		// close the late connection, nobody is going to use it
+		go func() {
+			closePools((<-done).pools)
+		}()
+		return nil, nil, ctx.Err()
+	}
+}
+
+// InitBackground keep connecting until ctx is done, for a degraded start, readiness fails until it succeeds
+func InitBackground(ctx context.Context, dataSource *DatasourceConfig) {
+	go func() {
+		for ctx.Err() == nil {
+			if err := Init(ctx, dataSource); err == nil {
+				logger.Infof(ctx, "gorm %s connected", dataSource.Driver())
+				return
+			}
+		}
+	}()
+}
+
+// Get the connected database, nil until Init succeeds
+func Get() *gorm.DB {
+	dbMu.RLock()
+	defer dbMu.RUnlock()
+	return defaultDB
+}
+
+func setDB(db *gorm.DB) {
+	dbMu.Lock()
+	defer dbMu.Unlock()
+	defaultDB = db
+}
+
+// setPools register the pools of the connected database for the stats
+func setPools(dbPools map[string]*sql.DB) {
+	poolsMu.Lock()
+	defer poolsMu.Unlock()
+	pools = dbPools
+}
+
+func closePools(dbPools map[string]*sql.DB) {
+	for _, pool := range dbPools {
+		pool.Close()
+	}
+}
+
+// Ping check the database connection, used by the readiness check
+func Ping(ctx context.Context) error {
+	db := Get()
+	if db == nil {
+		return errors.New("database is not connected")
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// WithContext a session of ctx, queries stop when ctx is cancelled.
+// Inside WithTx it is the transaction of ctx. Until Init succeeds its queries fail with ErrServiceUnavailable.
+func WithContext(ctx context.Context) *gorm.DB {
+	if tx, ok := txFrom(ctx); ok {
+		return tx.WithContext(ctx)
+	}
+	db := Get()
+	if db == nil {
+		return unconnected(ctx)
+	}
+	return db.WithContext(ctx)
+}
+
+// Reader a session reading from a replica, e.g. for heavy dashboard queries
+func Reader(ctx context.Context) *gorm.DB {
+	return WithContext(ctx).Clauses(dbresolver.Read)
+}
+
+// Writer a session on the primary, e.g. for reading right after a write
+func Writer(ctx context.Context) *gorm.DB {
+	return WithContext(ctx).Clauses(dbresolver.Write)
+}
+
+// Stats pool stats of the primary and every replica
+func Stats() map[string]sql.DBStats {
+	poolsMu.RLock()
+	defer poolsMu.RUnlock()
+
+	stats := make(map[string]sql.DBStats, len(pools))
+	for name, pool := range pools {
+		stats[name] = pool.Stats()
+	}
+	return stats
+}
+
+// NewDB open the database of DriverName, mysql when it is empty, reads go to the replicas if there are any
+func NewDB(ctx context.Context, dataSource *DatasourceConfig) (*gorm.DB, error) {
+	db, _, err := newDB(ctx, dataSource)
+	return db, err
+}
+
+// newDB NewDB and its pools by name, primary and replica-N
+func newDB(ctx context.Context, dataSource *DatasourceConfig) (*gorm.DB, map[string]*sql.DB, error) {
+	db, err := open(ctx, dataSource)
+	if err != nil {
+		return nil, nil, err
+	}
+	primary, _ := db.DB()
+	dbPools := map[string]*sql.DB{"primary": primary}
+
+	if len(dataSource.Replicas) == 0 {
+		return db, dbPools, nil
+	}
+
+	var replicas []gorm.Dialector
+	replicaPools := map[string]*sql.DB{}
+	for i, replicaConfig := range dataSource.Replicas {
+		replica := dataSource.replica(replicaConfig)
+		replicaDB, err := open(ctx, replica)
+		if err != nil {
+			logger.Errorf(ctx, "gorm %s replica %s start failed: %v", replica.Driver(), replica.Addr, err)
+			continue
+		}
+
+		conn, _ := replicaDB.DB()
+		dialector, _ := newDialector(replica, conn)
+		replicas = append(replicas, dialector)
+		replicaPools[fmt.Sprintf("replica-%d", i)] = conn
+	}
+
+	if len(replicas) > 0 {
+		err = db.Use(dbresolver.Register(dbresolver.Config{
+			Replicas: replicas,
+			Policy:   dbresolver.RandomPolicy{},
+		}))
+		if err != nil {
+			logger.Errorf(ctx, "gorm replicas start failed, reading from the primary: %v", err)
+			closePools(replicaPools)
+			return db, dbPools, nil
+		}
+	}
+
+	for name, pool := range replicaPools {
+		dbPools[name] = pool
+	}
+	return db, dbPools, nil
+}
+
+// open one database and its pool.
+// The connect is bounded by ctx and the dial timeout of the dsn.
+func open(ctx context.Context, dataSource *DatasourceConfig) (*gorm.DB, error) {
+	dialector, err := newDialector(dataSource, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// 打开链接
+	db, err := gorm.Open(dialector, newGormConfig())
+	if err != nil {
+		return nil, err
+	}
+
+	sqlDB, err := db.DB()
+	if err != nil {
+		return nil, err
+	}
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, err
+	}
+	configurePool(sqlDB, &dataSource.Pool)
+
+	return db, nil
+}
+
+func configurePool(sqlDB *sql.DB, pool *PoolConfig) {
+	maxIdleConns := pool.MaxIdleConns
+	if maxIdleConns == 0 {
+		maxIdleConns = defaultMaxIdleConns
+	}
+	maxOpenConns := pool.MaxOpenConns
+	if maxOpenConns == 0 {
+		maxOpenConns = defaultMaxOpenConns
+	}
+	connMaxLifetime := pool.ConnMaxLifetime
+	if connMaxLifetime == 0 {
+		connMaxLifetime = defaultConnMaxLifetime
+	}
+
+	sqlDB.SetMaxIdleConns(maxIdleConns)
+	sqlDB.SetMaxOpenConns(maxOpenConns)
+	sqlDB.SetConnMaxLifetime(connMaxLifetime)
+	sqlDB.SetConnMaxIdleTime(pool.ConnMaxIdleTime)
+}
+
+// newDialector conn is an already opened pool to reuse, nil to open one from the dsn
+func newDialector(dataSource *DatasourceConfig, conn gorm.ConnPool) (gorm.Dialector, error) {
+	switch dataSource.Driver() {
+	case MysqlDriver:
+		return newMysqlDialector(dataSource, conn), nil
+	case PostgresDriver:
+		return newPostgresDialector(dataSource, conn), nil
+	case SqliteDriver:
+		return newSqliteDialector(dataSource, conn), nil
+	}
+
+	return nil, fmt.Errorf("unsupported driver %q", dataSource.DriverName)
+}
+
+// newGormConfig gorm config shared by all drivers
+func newGormConfig() *gorm.Config {
+	return &gorm.Config{
+		// open pings with the startup ctx instead
+		DisableAutomaticPing: true,
+		NamingStrategy: schema.NamingStrategy{
+			SingularTable: true,
+		},
+		Logger: logger.NewGormLogger(),
+	}
+}
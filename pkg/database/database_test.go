@@ -0,0 +1,152 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"top-ping/pkg/baseerr"
+	"top-ping/pkg/logger"
+	"top-ping/pkg/tracing"
+	"top-ping/pkg/tracing/tracingtest"
+)
+
+type account struct {
+	ID      int64 `gorm:"primaryKey"`
+	Name    string
+	Balance int
+}
+
+func TestMain(m *testing.M) {
+	disabled := false
+	logger.Init("test", &logger.Config{Level: "error", Stdout: logger.OutputConfig{Enabled: &disabled}, File: logger.OutputConfig{Enabled: &disabled}})
+
+	dir, err := os.MkdirTemp("", "database")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	if err := Init(context.Background(), &DatasourceConfig{DriverName: SqliteDriver, Database: filepath.Join(dir, "test.db")}); err != nil {
+		panic(err)
+	}
+	if err := Get().AutoMigrate(&account{}); err != nil {
+		panic(err)
+	}
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestUnconnected(t *testing.T) {
+	connected := Get()
+	setDB(nil)
+	defer setDB(connected)
+
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		run  func() error
+	}{
+		{name: "find", run: func() error { return WithContext(ctx).Find(&[]account{}).Error }},
+		{name: "take", run: func() error { return WithContext(ctx).Model(&account{}).Where("id = ?", 1).Take(&account{}).Error }},
+		{name: "count", run: func() error { var n int64; return WithContext(ctx).Model(&account{}).Count(&n).Error }},
+		{name: "create", run: func() error { return WithContext(ctx).Create(&account{Name: "a"}).Error }},
+		{name: "delete", run: func() error { return WithContext(ctx).Where("id = ?", 1).Delete(&account{}).Error }},
+		{name: "reader", run: func() error { return Reader(ctx).Find(&[]account{}).Error }},
+		{name: "writer", run: func() error { return Writer(ctx).Find(&[]account{}).Error }},
+		{name: "tx", run: func() error { return WithTx(ctx, func(ctx context.Context) error { return nil }) }},
+		{name: "ping", run: func() error { return Ping(ctx) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.run()
+			if err == nil {
+				t.Fatal("no error while the database is not connected")
+			}
+			if tt.name != "tx" && tt.name != "ping" && !errors.Is(err, baseerr.ErrServiceUnavailable) {
+				t.Errorf("error = %v, want ErrServiceUnavailable", err)
+			}
+		})
+	}
+}
+
+func TestQuerySpan(t *testing.T) {
+	tests := []struct {
+		name      string
+		run       func(ctx context.Context) error
+		wantSQL   string
+		wantError bool
+	}{
+		{
+			name: "query",
+			run: func(ctx context.Context) error {
+				return WithContext(ctx).Where("name = ?", "span").Find(&[]account{}).Error
+			},
+			wantSQL: "SELECT * FROM `account` WHERE name = \"span\"",
+		},
+		{
+			name:      "failed query",
+			run:       func(ctx context.Context) error { return WithContext(ctx).Table("missing").Find(&[]account{}).Error },
+			wantSQL:   "SELECT * FROM `missing`",
+			wantError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracingtest.Install(t)
+			ctx, span := tracing.Start(context.Background(), "caller")
+			err := tt.run(ctx)
+			span.End()
+			if (err != nil) != tt.wantError {
+				t.Fatalf("query error = %v, want error %v", err, tt.wantError)
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 2 {
+				t.Fatalf("%d spans, want the query and the caller spans", len(spans))
+			}
+			query := spans[0]
+			if query.Name != "gorm.query" || query.SpanKind != trace.SpanKindClient || query.Parent.SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("span %q of kind %v, parent %s, want gorm.query of kind client, parent the caller", query.Name, query.SpanKind, query.Parent.SpanID())
+			}
+			var sql string
+			for _, kv := range query.Attributes {
+				if kv.Key == "db.query.text" {
+					sql = kv.Value.AsString()
+				}
+			}
+			if !strings.HasPrefix(sql, tt.wantSQL) {
+				t.Errorf("db.query.text %q, want %q", sql, tt.wantSQL)
+			}
+			if (query.Status.Code == codes.Error) != tt.wantError {
+				t.Errorf("span status %v, want error %v", query.Status.Code, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestPoolsRegisteredOnConnect(t *testing.T) {
+	connected := Get()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := Init(ctx, &DatasourceConfig{DriverName: SqliteDriver, Database: filepath.Join(t.TempDir(), "other.db")})
+	if err == nil {
+		t.Fatal("Init() with a done ctx succeeded")
+	}
+	if Get() != connected {
+		t.Error("failed Init() replaced the database")
+	}
+
+	stats := Stats()
+	if _, ok := stats["primary"]; !ok || len(stats) != 1 {
+		t.Errorf("Stats() = %v, want the primary pool only", stats)
+	}
+}
@@ -1,38 +1,43 @@
 package database
 
 import (
-	"context"
 	"fmt"
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
-	"gorm.io/gorm/schema"
-	"sync"
-	"time"
-	"top-ping/pkg/logger"
+	"net/url"
 )
 
-var (
-	DB       *gorm.DB
-	initOnce sync.Once
-)
-
-func Init(dataSource *DatasourceConfig) {
-	initOnce.Do(func() {
-		DB = NewMysqlDB(dataSource)
-	})
-}
+const defaultMysqlPort = 3306
 
-func NewMysqlDB(dataSource *DatasourceConfig) *gorm.DB {
-	host := dataSource.Addr
-	database := dataSource.Database
-	username := dataSource.User
-	password := dataSource.Password
-	charset := dataSource.Charset
+func newMysqlDialector(dataSource *DatasourceConfig, conn gorm.ConnPool) gorm.Dialector {
+	params := url.Values{}
+	if dataSource.Charset != "" {
+		params.Set("charset", dataSource.Charset)
+	}
+	params.Set("parseTime", "True")
+	// rows affected counts matched rows, an update to the same values is not taken for a missing row
+	params.Set("clientFoundRows", "true")
+	params.Set("loc", dataSource.Location())
+	if dataSource.TLS != "" {
+		params.Set("tls", dataSource.TLS)
+	}
+	params.Set("timeout", dataSource.ConnectTimeoutOr(defaultConnectTimeout).String())
+	if dataSource.ReadTimeout > 0 {
+		params.Set("readTimeout", dataSource.ReadTimeout.String())
+	}
+	if dataSource.WriteTimeout > 0 {
+		params.Set("writeTimeout", dataSource.WriteTimeout.String())
+	}
+	for k, v := range dataSource.extraParams() {
+		params.Set(k, v)
+	}
 
 	// 拼接mysql相关配置
-	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?charset=%s&parseTime=True&loc=Local", username, password, host, 3306, database, charset)
+	dsn := fmt.Sprintf("%s:%s@tcp(%s:%d)/%s?%s",
+		dataSource.User, dataSource.Password, dataSource.Addr, dataSource.PortOr(defaultMysqlPort), dataSource.Database, params.Encode())
 	mysqlConfig := mysql.Config{
 		DSN:                       dsn,   // DSN data source name
+		Conn:                      conn,  // 已打开的连接，为空时按 DSN 打开
 		DefaultStringSize:         256,   // string 类型字段的默认长度
 		DisableDatetimePrecision:  true,  // 禁用 datetime 精度，MySQL 5.6 之前的数据库不支持
 		DontSupportRenameIndex:    true,  // 重命名索引时采用删除并新建的方式，MySQL 5.7 之前的数据库和 MariaDB 不支持重命名索引
@@ -40,22 +45,5 @@ func NewMysqlDB(dataSource *DatasourceConfig) *gorm.DB {
 		SkipInitializeWithVersion: false, // 根据版本自动配置
 	}
 
-	// 打开链接
-	db, err := gorm.Open(mysql.New(mysqlConfig), &gorm.Config{
-		NamingStrategy: schema.NamingStrategy{
-			SingularTable: true,
-		},
-		Logger: logger.NewGormLogger(),
-	})
-
-	if err != nil {
-		logger.Errorf(context.Background(), "gorm mysql start failed: %v", err)
-		return nil
-	}
-
-	sqlDB, _ := db.DB()
-	sqlDB.SetMaxIdleConns(10)
-	sqlDB.SetMaxOpenConns(100)
-	sqlDB.SetConnMaxLifetime(time.Hour)
-	return db
+	return mysql.New(mysqlConfig)
 }
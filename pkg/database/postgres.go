@@ -0,0 +1,59 @@
+package database
+
+import (
+	"fmt"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"math"
+	"sort"
+	"strings"
+)
+
+const defaultPostgresPort = 5432
+
+func newPostgresDialector(dataSource *DatasourceConfig, conn gorm.ConnPool) gorm.Dialector {
+	sslMode := dataSource.TLS
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	params := map[string]string{
+		"host":     dataSource.Addr,
+		"port":     fmt.Sprint(dataSource.PortOr(defaultPostgresPort)),
+		"user":     dataSource.User,
+		"password": dataSource.Password,
+		"dbname":   dataSource.Database,
+		"sslmode":  sslMode,
+		"TimeZone": dataSource.Location(),
+	}
+	if dataSource.Charset != "" {
+		params["client_encoding"] = dataSource.Charset
+	}
+	// libpq has no read/write timeouts, only the connect timeout in seconds
+	params["connect_timeout"] = fmt.Sprint(int(math.Ceil(dataSource.ConnectTimeoutOr(defaultConnectTimeout).Seconds())))
+	for k, v := range dataSource.extraParams() {
+		params[k] = v
+	}
+
+	return postgres.New(postgres.Config{
+		DSN:  keyValueDSN(params),
+		Conn: conn,
+	})
+}
+
+// keyValueDSN libpq key=value connection string, values are quoted so spaces and quotes are safe
+func keyValueDSN(params map[string]string) string {
+	keys := make([]string, 0, len(params))
+	for k := range params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	replacer := strings.NewReplacer(`\`, `\\`, `'`, `\'`)
+	for _, k := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s='%s'", k, replacer.Replace(params[k])))
+	}
+
+	return strings.Join(pairs, " ")
+}
@@ -0,0 +1,23 @@
+package database
+
+import (
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"net/url"
+)
+
+// newSqliteDialector Database is the path of the db file, or ":memory:"
+func newSqliteDialector(dataSource *DatasourceConfig, conn gorm.ConnPool) gorm.Dialector {
+	params := url.Values{}
+	params.Set("_busy_timeout", "5000")
+	params.Set("_foreign_keys", "1")
+	params.Set("_loc", dataSource.Location())
+	for k, v := range dataSource.extraParams() {
+		params.Set(k, v)
+	}
+
+	return &sqlite.Dialector{
+		DSN:  dataSource.Database + "?" + params.Encode(),
+		Conn: conn,
+	}
+}
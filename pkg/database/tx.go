@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"top-ping/pkg/baseerr"
+)
+
+type txKey struct{}
+
+// WithTx run fn in a transaction, sessions from WithContext(ctx) in fn join it, so do nested WithTx calls.
+// It commits when fn returns nil and rolls back on an error or a panic, the panic goes on after the rollback.
+// baseerr errors of fn are returned as they are, other failures as ErrInvalidTransaction wrapping the cause.
+func WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := txFrom(ctx); ok {
+		return fn(ctx)
+	}
+
+	db := Get()
+	if db == nil {
+		return baseerr.ErrInvalidTransaction.Wrap(errors.New("database is not connected"))
+	}
+
+	tx := db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return baseerr.ErrInvalidTransaction.Wrap(tx.Error)
+	}
+
+	committed := false
+	defer func() {
+		if !committed {
+			tx.Rollback()
+		}
+	}()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx)); err != nil {
+		var baseErr *baseerr.Error
+		if errors.As(err, &baseErr) {
+			return err
+		}
+		return baseerr.ErrInvalidTransaction.Wrap(err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return baseerr.ErrInvalidTransaction.Wrap(err)
+	}
+	committed = true
+	return nil
+}
+
+func txFrom(ctx context.Context) (*gorm.DB, bool) {
+	tx, ok := ctx.Value(txKey{}).(*gorm.DB)
+	return tx, ok
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"top-ping/pkg/baseerr"
+)
+
+func TestWithTx(t *testing.T) {
+	boom := errors.New("boom")
+	notFound := fmt.Errorf("account 1: %w", baseerr.ErrNotFound)
+	create := func(ctx context.Context, name string) error {
+		return WithContext(ctx).Create(&account{Name: name}).Error
+	}
+
+	tests := []struct {
+		name string
+		fn   func(ctx context.Context) error
+		// want the names of the accounts after the transaction
+		want []string
+		// err matched by errors.Is, wrapped in ErrInvalidTransaction or returned as it is
+		err     error
+		wrapped bool
+		panics  bool
+	}{
+		{
+			name: "commit",
+			fn:   func(ctx context.Context) error { return create(ctx, "a") },
+			want: []string{"a"},
+		},
+		{
+			name: "rollback on an error",
+			fn: func(ctx context.Context) error {
+				if err := create(ctx, "a"); err != nil {
+					return err
+				}
+				return boom
+			},
+			err:     boom,
+			wrapped: true,
+		},
+		{
+			name: "baseerr errors unwrapped",
+			fn: func(ctx context.Context) error {
+				if err := create(ctx, "a"); err != nil {
+					return err
+				}
+				return notFound
+			},
+			err: notFound,
+		},
+		{
+			name: "rollback on a panic",
+			fn: func(ctx context.Context) error {
+				if err := create(ctx, "a"); err != nil {
+					return err
+				}
+				panic(boom)
+			},
+			panics: true,
+		},
+		{
+			name: "nested joins the outer",
+			fn: func(ctx context.Context) error {
+				outer, _ := txFrom(ctx)
+				if err := create(ctx, "a"); err != nil {
+					return err
+				}
+				return WithTx(ctx, func(ctx context.Context) error {
+					if inner, _ := txFrom(ctx); inner != outer {
+						t.Error("nested WithTx began another transaction")
+					}
+					return create(ctx, "b")
+				})
+			},
+			want: []string{"a", "b"},
+		},
+		{
+			name: "nested error rolls back the outer",
+			fn: func(ctx context.Context) error {
+				if err := create(ctx, "a"); err != nil {
+					return err
+				}
+				return WithTx(ctx, func(ctx context.Context) error {
+					if err := create(ctx, "b"); err != nil {
+						return err
+					}
+					return boom
+				})
+			},
+			err:     boom,
+			wrapped: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if err := Get().Where("1 = 1").Delete(&account{}).Error; err != nil {
+				t.Fatal(err)
+			}
+
+			var err error
+			recovered := func() (recovered interface{}) {
+				defer func() { recovered = recover() }()
+				err = WithTx(ctx, tt.fn)
+				return nil
+			}()
+			if (recovered != nil) != tt.panics {
+				t.Fatalf("WithTx() panic = %v, want panic %v", recovered, tt.panics)
+			}
+			if tt.panics && recovered != boom {
+				t.Errorf("WithTx() panic = %v, want the panic of fn", recovered)
+			}
+
+			switch {
+			case tt.err == nil && err != nil:
+				t.Errorf("WithTx() error = %v", err)
+			case tt.err != nil && !errors.Is(err, tt.err):
+				t.Errorf("WithTx() error = %v, want %v", err, tt.err)
+			case tt.err != nil && errors.Is(err, baseerr.ErrInvalidTransaction) != tt.wrapped:
+				t.Errorf("WithTx() error = %v, want wrapped in ErrInvalidTransaction %v", err, tt.wrapped)
+			case tt.err != nil && !tt.wrapped && err != tt.err:
+				t.Errorf("WithTx() error = %v, want fn's error as it is", err)
+			}
+
+			var names []string
+			if err := Get().Model(&account{}).Order("id").Pluck("name", &names).Error; err != nil {
+				t.Fatal(err)
+			}
+			if len(names) == 0 {
+				names = nil
+			}
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("accounts %v after WithTx(), want %v", names, tt.want)
+			}
+		})
+	}
+}
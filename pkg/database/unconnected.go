@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	gormlogger "gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+	"sync"
+	"top-ping/pkg/baseerr"
+)
+
+var (
+	unconnectedOnce sync.Once
+	unconnectedDB   *gorm.DB
+)
+
+// unconnected a session failing every query with ErrServiceUnavailable, returned by WithContext
+// until the database is connected, e.g. during a degraded start, so callers get an error instead of a nil db
+func unconnected(ctx context.Context) *gorm.DB {
+	unconnectedOnce.Do(func() {
+		unconnectedDB, _ = gorm.Open(unconnectedDialector{}, &gorm.Config{
+			DisableAutomaticPing: true,
+			NamingStrategy:       schema.NamingStrategy{SingularTable: true},
+			Logger:               gormlogger.Discard,
+		})
+	})
+
+	db := unconnectedDB.WithContext(ctx)
+	_ = db.AddError(baseerr.ErrServiceUnavailable)
+	return db
+}
+
+// unconnectedDialector registers no callbacks, queries never reach a connection
+type unconnectedDialector struct{}
+
+func (unconnectedDialector) Name() string {
+	return "unconnected"
+}
+
+func (unconnectedDialector) Initialize(*gorm.DB) error {
+	return nil
+}
+
+func (d unconnectedDialector) Migrator(db *gorm.DB) gorm.Migrator {
+	return migrator.Migrator{Config: migrator.Config{DB: db, Dialector: d}}
+}
+
+func (unconnectedDialector) DataTypeOf(*schema.Field) string {
+	return ""
+}
+
+func (unconnectedDialector) DefaultValueOf(*schema.Field) clause.Expression {
+	return clause.Expr{SQL: "DEFAULT"}
+}
+
+func (unconnectedDialector) BindVarTo(writer clause.Writer, _ *gorm.Statement, _ interface{}) {
+	_ = writer.WriteByte('?')
+}
+
+func (unconnectedDialector) QuoteTo(writer clause.Writer, str string) {
+	_, _ = writer.WriteString(str)
+}
+
+func (unconnectedDialector) Explain(sql string, _ ...interface{}) string {
+	return sql
+}
@@ -0,0 +1,8 @@
+package health
+
+import "time"
+
+type Config struct {
+	Timeout       time.Duration `mapstructure:"timeout"`
+	MinFreeDiskMB uint64        `mapstructure:"minFreeDiskMB"`
+}
@@ -0,0 +1,22 @@
+package health
+
+import (
+	"context"
+	"fmt"
+)
+
+// DiskSpace check that the filesystem of dir has at least minFreeMB free
+func DiskSpace(dir string, minFreeMB uint64) Check {
+	return func(ctx context.Context) error {
+		free, err := freeBytes(dir)
+		if err != nil {
+			return err
+		}
+
+		freeMB := free / 1024 / 1024
+		if freeMB < minFreeMB {
+			return fmt.Errorf("%s has %dMB free, below %dMB", dir, freeMB, minFreeMB)
+		}
+		return nil
+	}
+}
@@ -0,0 +1,124 @@
+package health
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+
+	defaultTimeout = 2 * time.Second
+)
+
+// Check a readiness check, a nil error means the dependency is ready
+type Check func(ctx context.Context) error
+
+type CheckResult struct {
+	Status  string `json:"status"`
+	Latency string `json:"latency,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+var (
+	mu           sync.RWMutex
+	checks       = map[string]Check{}
+	timeout      = defaultTimeout
+	shuttingDown int32
+)
+
+// Public the report without errors and latencies, which tell about the internals, e.g. hosts of dependencies
+func (r *Report) Public() *Report {
+	public := &Report{Status: r.Status, Checks: make(map[string]CheckResult, len(r.Checks))}
+	for name, result := range r.Checks {
+		public.Checks[name] = CheckResult{Status: result.Status}
+	}
+	return public
+}
+
+// Register add a readiness check, components such as the database register themselves at startup
+func Register(name string, check Check) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	checks[name] = check
+}
+
+func SetTimeout(d time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if d <= 0 {
+		d = defaultTimeout
+	}
+	timeout = d
+}
+
+// SetShuttingDown make readiness fail so load balancers drain the instance before it stops
+func SetShuttingDown() {
+	atomic.StoreInt32(&shuttingDown, 1)
+}
+
+func ShuttingDown() bool {
+	return atomic.LoadInt32(&shuttingDown) == 1
+}
+
+// Readiness run all checks concurrently, the instance is ready only when all of them pass
+func Readiness(ctx context.Context) (*Report, bool) {
+	mu.RLock()
+	current := make(map[string]Check, len(checks))
+	for name, check := range checks {
+		current[name] = check
+	}
+	checkTimeout := timeout
+	mu.RUnlock()
+
+	report := &Report{Status: StatusUp, Checks: map[string]CheckResult{}}
+	if ShuttingDown() {
+		report.Status = StatusDown
+		report.Checks["shutdown"] = CheckResult{Status: StatusDown, Error: "server is shutting down"}
+	}
+
+	var wg sync.WaitGroup
+	var resultMu sync.Mutex
+	for name, check := range current {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+			result := run(ctx, check, checkTimeout)
+
+			resultMu.Lock()
+			defer resultMu.Unlock()
+			report.Checks[name] = result
+			if result.Status != StatusUp {
+				report.Status = StatusDown
+			}
+		}(name, check)
+	}
+	wg.Wait()
+
+	return report, report.Status == StatusUp
+}
+
+func run(ctx context.Context, check Check, checkTimeout time.Duration) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, checkTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{Status: StatusUp, Latency: time.Since(start).String()}
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+
+	return result
+}
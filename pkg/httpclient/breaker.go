@@ -0,0 +1,100 @@
+package httpclient
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var ErrCircuitOpen = errors.New("httpclient: circuit breaker is open")
+
+type breakerState int
+
+const (
+	stateClosed breakerState = iota
+	stateOpen
+	stateHalfOpen
+)
+
+// BreakerConfig the circuit of a host opens after FailureThreshold consecutive failures,
+// after OpenTimeout one trial request is let through to decide whether to close it again
+type BreakerConfig struct {
+	FailureThreshold int
+	OpenTimeout      time.Duration
+}
+
+var DefaultBreakerConfig = BreakerConfig{
+	FailureThreshold: 5,
+	OpenTimeout:      30 * time.Second,
+}
+
+type breakers struct {
+	mu     sync.Mutex
+	config BreakerConfig
+	hosts  map[string]*breaker
+}
+
+type breaker struct {
+	state    breakerState
+	failures int
+	openedAt time.Time
+	trial    bool
+}
+
+func newBreakers(config BreakerConfig) *breakers {
+	return &breakers{config: config, hosts: map[string]*breaker{}}
+}
+
+// allow whether a request to host may be sent now
+func (b *breakers) allow(host string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := b.host(host)
+	switch h.state {
+	case stateOpen:
+		if time.Since(h.openedAt) < b.config.OpenTimeout {
+			return false
+		}
+		h.state = stateHalfOpen
+		h.trial = true
+		return true
+	case stateHalfOpen:
+		// only the trial request is in flight
+		if h.trial {
+			return false
+		}
+		h.trial = true
+		return true
+	}
+
+	return true
+}
+
+func (b *breakers) record(host string, success bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	h := b.host(host)
+	h.trial = false
+	if success {
+		h.state = stateClosed
+		h.failures = 0
+		return
+	}
+
+	h.failures++
+	if h.state == stateHalfOpen || h.failures >= b.config.FailureThreshold {
+		h.state = stateOpen
+		h.openedAt = time.Now()
+	}
+}
+
+func (b *breakers) host(host string) *breaker {
+	h, ok := b.hosts[host]
+	if !ok {
+		h = &breaker{}
+		b.hosts[host] = h
+	}
+	return h
+}
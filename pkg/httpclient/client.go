@@ -0,0 +1,130 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	maxIdleConns        = 100
+	maxConnsPerHost     = 100
+	maxIdleConnsPerHost = 100
+)
+
+// Default the shared client of webhooks and agent-to-server calls
+var Default = New(DefaultBreakerConfig)
+
+// Client outbound http client with per-call timeout, retries of idempotent requests,
+// a circuit breaker per host and a logging, tracing transport
+type Client struct {
+	client   *http.Client
+	breakers *breakers
+}
+
+func New(breakerConfig BreakerConfig) *Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = maxIdleConns
+	t.MaxConnsPerHost = maxConnsPerHost
+	t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+
+	return &Client{
+		client:   &http.Client{Transport: &loggingTransport{next: t}},
+		breakers: newBreakers(breakerConfig),
+	}
+}
+
+// Do send req, ctx cancellation and the timeout cover all attempts and reading the response body
+func (c *Client) Do(ctx context.Context, req *http.Request, opts ...Option) (*http.Response, error) {
+	o := defaultOptions()
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	var cancel context.CancelFunc
+	if o.timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, o.timeout)
+	} else {
+		ctx, cancel = context.WithCancel(ctx)
+	}
+	attempts := o.retry.MaxAttempts
+	// a body which cannot be rewound cannot be sent twice
+	if attempts < 1 || !isIdempotent(req.Method) || (req.Body != nil && req.Body != http.NoBody && req.GetBody == nil) {
+		attempts = 1
+	}
+
+	host := req.URL.Host
+	var res *http.Response
+	var err error
+	for attempt := 0; ; attempt++ {
+		if o.breaker && !c.breakers.allow(host) {
+			cancel()
+			return nil, ErrCircuitOpen
+		}
+
+		res, err = c.client.Do(req.WithContext(ctx))
+		if o.breaker {
+			c.breakers.record(host, err == nil && res.StatusCode < http.StatusInternalServerError)
+		}
+
+		if (err == nil && !o.retry.retryable(res.StatusCode)) || attempt == attempts-1 || ctx.Err() != nil {
+			break
+		}
+		// the last response is returned as it is when no further attempt can be made
+		if wait(ctx, o.retry.backoff(attempt)) != nil {
+			break
+		}
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				break
+			}
+			req.Body = body
+		}
+		if res != nil {
+			drain(res)
+		}
+	}
+
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return wrapBody(res, cancel), nil
+}
+
+func wait(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// drain read the rest of a discarded response so the connection can be reused
+func drain(res *http.Response) {
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 4096))
+	_ = res.Body.Close()
+}
+
+// wrapBody the timeout context lives until the caller closes the body
+func wrapBody(res *http.Response, cancel context.CancelFunc) *http.Response {
+	res.Body = &cancelBody{ReadCloser: res.Body, cancel: cancel}
+	return res
+}
+
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,224 @@
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+	"top-ping/pkg/logger"
+	"top-ping/pkg/tracing"
+	"top-ping/pkg/tracing/tracingtest"
+	"top-ping/pkg/utils"
+)
+
+func TestMain(m *testing.M) {
+	disabled := false
+	logger.Init("test", &logger.Config{Level: "error", Stdout: logger.OutputConfig{Enabled: &disabled}, File: logger.OutputConfig{Enabled: &disabled}})
+	os.Exit(m.Run())
+}
+
+func TestDo(t *testing.T) {
+	fastRetry := WithRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond, RetryStatus: []int{http.StatusServiceUnavailable}})
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		statuses []int
+		delay    time.Duration
+		opts     []Option
+		attempts int32
+		status   int
+		err      error
+	}{
+		{name: "ok", method: http.MethodGet, statuses: []int{200}, opts: []Option{fastRetry}, attempts: 1, status: 200},
+		{name: "retried until ok", method: http.MethodGet, statuses: []int{503, 503, 200}, opts: []Option{fastRetry}, attempts: 3, status: 200},
+		{name: "last response after all attempts", method: http.MethodGet, statuses: []int{503, 503, 503, 200}, opts: []Option{fastRetry}, attempts: 3, status: 503},
+		{name: "status not retryable", method: http.MethodGet, statuses: []int{500, 200}, opts: []Option{fastRetry}, attempts: 1, status: 500},
+		{name: "post not retried", method: http.MethodPost, body: "x", statuses: []int{503, 200}, opts: []Option{fastRetry}, attempts: 1, status: 503},
+		{name: "put retried with its body", method: http.MethodPut, body: "x", statuses: []int{503, 200}, opts: []Option{fastRetry}, attempts: 2, status: 200},
+		{name: "without retry", method: http.MethodGet, statuses: []int{503, 200}, opts: []Option{fastRetry, WithoutRetry()}, attempts: 1, status: 503},
+		{name: "timeout", method: http.MethodGet, statuses: []int{200}, delay: 200 * time.Millisecond, opts: []Option{WithTimeout(20 * time.Millisecond)}, attempts: 1, err: context.DeadlineExceeded},
+		{name: "no timeout", method: http.MethodGet, statuses: []int{200}, delay: 20 * time.Millisecond, opts: []Option{WithTimeout(0)}, attempts: 1, status: 200},
+		{name: "negative timeout", method: http.MethodGet, statuses: []int{200}, opts: []Option{WithTimeout(-time.Second)}, attempts: 1, status: 200},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&attempts, 1)
+				body := make([]byte, 8)
+				if read, _ := r.Body.Read(body); string(body[:read]) != tt.body {
+					t.Errorf("attempt %d got body %q, want %q", n, body[:read], tt.body)
+				}
+				time.Sleep(tt.delay)
+				w.WriteHeader(tt.statuses[int(n)-1])
+			}))
+			defer srv.Close()
+
+			req, _ := http.NewRequest(tt.method, srv.URL, strings.NewReader(tt.body))
+			if tt.body == "" {
+				req, _ = http.NewRequest(tt.method, srv.URL, nil)
+			}
+			res, err := New(DefaultBreakerConfig).Do(context.Background(), req, tt.opts...)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Fatalf("Do() error = %v, want %v", err, tt.err)
+				}
+			} else {
+				if err != nil {
+					t.Fatalf("Do() error = %v", err)
+				}
+				res.Body.Close()
+				if res.StatusCode != tt.status {
+					t.Errorf("Do() status = %d, want %d", res.StatusCode, tt.status)
+				}
+			}
+			if got := atomic.LoadInt32(&attempts); got != tt.attempts {
+				t.Errorf("Do() sent %d attempts, want %d", got, tt.attempts)
+			}
+		})
+	}
+}
+
+func TestTraceHeader(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = append(got, r.Header.Get(utils.TraceKey))
+	}))
+	defer srv.Close()
+
+	ctxs := []context.Context{
+		context.WithValue(context.Background(), utils.TraceKey, "abcd1234ef"),
+		context.WithValue(context.Background(), utils.TraceKey, "bad id"),
+		context.Background(),
+	}
+	for _, ctx := range ctxs {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		res, err := New(DefaultBreakerConfig).Do(ctx, req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+	}
+
+	if got[0] != "abcd1234ef" {
+		t.Errorf("trace id %q, want the one of ctx", got[0])
+	}
+	for _, id := range got[1:] {
+		if id == "" || id == "bad id" {
+			t.Errorf("trace id %q, want a new one", id)
+		}
+	}
+}
+
+func TestTraceContext(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		header    string
+		wantError bool
+	}{
+		{name: "ok", status: http.StatusOK},
+		{name: "server error", status: http.StatusBadGateway, wantError: true},
+		{name: "legacy header of the caller kept", status: http.StatusOK, header: "abcd1234ef"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			exporter := tracingtest.Install(t)
+			var traceparent, tracestate, legacy string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				traceparent, tracestate, legacy = r.Header.Get("traceparent"), r.Header.Get("tracestate"), r.Header.Get(utils.TraceKey)
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			// the caller's span, continued from a traceparent and tracestate
+			state, _ := trace.ParseTraceState("vendor=abc")
+			parent := trace.NewSpanContext(trace.SpanContextConfig{TraceID: trace.TraceID{1}, SpanID: trace.SpanID{2}, TraceFlags: trace.FlagsSampled, TraceState: state, Remote: true})
+			ctx, span := tracing.Start(trace.ContextWithRemoteSpanContext(context.Background(), parent), "caller")
+			ctx = context.WithValue(ctx, utils.TraceKey, "fromctx1234")
+
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/items", nil)
+			if tt.header != "" {
+				req.Header.Set(utils.TraceKey, tt.header)
+			}
+			res, err := New(DefaultBreakerConfig).Do(ctx, req, WithoutRetry())
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			res.Body.Close()
+			span.End()
+
+			if req.Header.Get("traceparent") != "" {
+				t.Error("Do() modified the caller's request")
+			}
+
+			spans := exporter.GetSpans()
+			if len(spans) != 2 {
+				t.Fatalf("%d spans, want the client and the caller spans", len(spans))
+			}
+			client := spans[0]
+			if client.Name != "HTTP GET" || client.SpanKind != trace.SpanKindClient || client.Parent.SpanID() != span.SpanContext().SpanID() {
+				t.Errorf("span %q of kind %v, parent %s, want HTTP GET of kind client, parent the caller", client.Name, client.SpanKind, client.Parent.SpanID())
+			}
+			if client.SpanContext.TraceID() != parent.TraceID() {
+				t.Errorf("client span of trace %s, want the caller's %s", client.SpanContext.TraceID(), parent.TraceID())
+			}
+			if (client.Status.Code == codes.Error) != tt.wantError {
+				t.Errorf("span status %v, want error %v", client.Status.Code, tt.wantError)
+			}
+
+			want := "00-" + parent.TraceID().String() + "-" + client.SpanContext.SpanID().String() + "-01"
+			if traceparent != want || tracestate != "vendor=abc" {
+				t.Errorf("traceparent %q, tracestate %q, want %q, %q", traceparent, tracestate, want, "vendor=abc")
+			}
+			wantLegacy := tt.header
+			if wantLegacy == "" {
+				wantLegacy = "fromctx1234"
+			}
+			if legacy != wantLegacy {
+				t.Errorf("%s header %q, want %q", utils.TraceKey, legacy, wantLegacy)
+			}
+		})
+	}
+}
+
+func TestBreaker(t *testing.T) {
+	var attempts int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	client := New(BreakerConfig{FailureThreshold: 2, OpenTimeout: time.Hour})
+	for i, want := range []error{nil, nil, ErrCircuitOpen, ErrCircuitOpen} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+		res, err := client.Do(context.Background(), req, WithoutRetry())
+		if !errors.Is(err, want) {
+			t.Fatalf("call %d: Do() error = %v, want %v", i, err, want)
+		}
+		if res != nil {
+			res.Body.Close()
+		}
+	}
+	if attempts != 2 {
+		t.Errorf("server got %d requests, want 2", attempts)
+	}
+
+	req, _ := http.NewRequest(http.MethodGet, srv.URL, nil)
+	res, err := client.Do(context.Background(), req, WithoutRetry(), WithoutBreaker())
+	if err != nil {
+		t.Fatalf("Do() without breaker error = %v", err)
+	}
+	res.Body.Close()
+}
@@ -0,0 +1,62 @@
+package httpclient
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+)
+
+type options struct {
+	timeout time.Duration
+	retry   RetryPolicy
+	breaker bool
+}
+
+// Option per-call option of Client.Do
+type Option func(*options)
+
+// WithTimeout timeout of the whole call including retries, 0 or less for none, ctx still bounds the call
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithRetry retry idempotent requests on network errors and retryable status codes
+func WithRetry(policy RetryPolicy) Option {
+	return func(o *options) {
+		o.retry = policy
+	}
+}
+
+func WithoutRetry() Option {
+	return func(o *options) {
+		o.retry = RetryPolicy{MaxAttempts: 1}
+	}
+}
+
+// WithoutBreaker bypass the circuit breaker of the host, e.g. for health probes of the host itself
+func WithoutBreaker() Option {
+	return func(o *options) {
+		o.breaker = false
+	}
+}
+
+func defaultOptions() options {
+	return options{
+		timeout: defaultTimeout,
+		retry:   DefaultRetryPolicy,
+		breaker: true,
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
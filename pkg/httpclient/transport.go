@@ -0,0 +1,66 @@
+package httpclient
+
+import (
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+	"top-ping/pkg/idgen"
+	"top-ping/pkg/logger"
+	"top-ping/pkg/tracing"
+	"top-ping/pkg/utils"
+)
+
+// loggingTransport trace and log every attempt sent by the client
+type loggingTransport struct {
+	next http.RoundTripper
+}
+
+func (t *loggingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := tracing.Start(req.Context(), "HTTP "+req.Method,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.request.method", req.Method),
+			attribute.String("url.full", req.URL.Redacted()),
+		),
+	)
+	defer span.End()
+
+	// a RoundTripper must not modify the caller's request
+	req = req.Clone(ctx)
+	tracing.Propagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	if req.Header.Get(utils.TraceKey) == "" {
+		traceId, ok := ctx.Value(utils.TraceKey).(string)
+		if !ok || !idgen.Valid(traceId) {
+			traceId = idgen.NewID()
+		}
+		req.Header.Set(utils.TraceKey, traceId)
+	}
+
+	start := time.Now()
+	res, err := t.next.RoundTrip(req)
+	fields := []zap.Field{
+		zap.String("Method", req.Method),
+		zap.String("URL", req.URL.Redacted()),
+		zap.Any("Header", logger.CurrentConfig().RedactHeader(req.Header)),
+		zap.Duration("Latency", time.Since(start)),
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		logger.Warn(ctx, "HttpClientLog", append(fields, zap.Error(err))...)
+		return nil, err
+	}
+
+	span.SetAttributes(attribute.Int("http.response.status_code", res.StatusCode))
+	if res.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, http.StatusText(res.StatusCode))
+	}
+	logger.Info(ctx, "HttpClientLog", append(fields, zap.Int("Status", res.StatusCode))...)
+
+	return res, nil
+}
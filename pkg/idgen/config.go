@@ -0,0 +1,17 @@
+package idgen
+
+import "fmt"
+
+type Config struct {
+	// Generator random, ulid or uuidv7, random when empty
+	Generator string `mapstructure:"generator"`
+	// Length of random ids, 10 when 0
+	Length int `mapstructure:"length"`
+}
+
+func (c *Config) Validate() error {
+	if _, err := New(c); err != nil {
+		return fmt.Errorf("traceId.%v", err)
+	}
+	return nil
+}
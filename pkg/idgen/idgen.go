@@ -0,0 +1,160 @@
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	RandomGenerator = "random"
+	ULIDGenerator   = "ulid"
+	UUIDv7Generator = "uuidv7"
+
+	defaultLength = 10
+	minValidLen   = 8
+	maxValidLen   = 64
+
+	alphabet = "0123456789abcdefghijklmnopqrstuvwxyz"
+	// crockford base32 of ulid
+	crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+)
+
+// Generator generates request and trace ids, safe for concurrent use
+type Generator interface {
+	NewID() string
+}
+
+// holder atomic.Value only takes values of one concrete type
+type holder struct {
+	generator Generator
+}
+
+var current atomic.Value
+
+func init() {
+	current.Store(holder{Random{Length: defaultLength}})
+}
+
+// New the generator of config
+func New(config *Config) (Generator, error) {
+	switch config.Generator {
+	case "", RandomGenerator:
+		length := config.Length
+		if length == 0 {
+			length = defaultLength
+		}
+		if length < minValidLen || length > maxValidLen {
+			return nil, fmt.Errorf("length: must be between %d and %d, got %d", minValidLen, maxValidLen, length)
+		}
+		return Random{Length: length}, nil
+	case ULIDGenerator:
+		return ULID{}, nil
+	case UUIDv7Generator:
+		return UUIDv7{}, nil
+	}
+	return nil, fmt.Errorf("generator: must be %s, %s or %s, got %q", RandomGenerator, ULIDGenerator, UUIDv7Generator, config.Generator)
+}
+
+// SetDefault the generator used by NewID
+func SetDefault(g Generator) {
+	current.Store(holder{g})
+}
+
+func NewID() string {
+	return current.Load().(holder).generator.NewID()
+}
+
+// Valid whether an incoming id can be trusted for logs: 8 to 64 of [0-9A-Za-z_-]
+func Valid(id string) bool {
+	if len(id) < minValidLen || len(id) > maxValidLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !(c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '-' || c == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// Random n chars of [0-9a-z] from crypto/rand
+type Random struct {
+	Length int
+}
+
+func (g Random) NewID() string {
+	return RandomString(g.Length)
+}
+
+// RandomString n chars of [0-9a-z] from crypto/rand, without modulo bias
+func RandomString(n int) string {
+	buf := make([]byte, n)
+	// the largest multiple of len(alphabet) below 256, bytes above it are rejected
+	limit := byte(256 - 256%len(alphabet))
+	random := make([]byte, n+n/4+1)
+	for i := 0; i < n; {
+		randomBytes(random)
+		for _, b := range random {
+			if b >= limit {
+				continue
+			}
+			buf[i] = alphabet[int(b)%len(alphabet)]
+			i++
+			if i == n {
+				break
+			}
+		}
+	}
+	return string(buf)
+}
+
+// ULID 48 bits of unix milliseconds and 80 random bits in 26 crockford base32 chars, sortable by time
+type ULID struct{}
+
+func (ULID) NewID() string {
+	var id [16]byte
+	putMillis(id[:6], time.Now())
+	randomBytes(id[6:])
+
+	hi := binary.BigEndian.Uint64(id[:8])
+	lo := binary.BigEndian.Uint64(id[8:])
+	var out [26]byte
+	for i := len(out) - 1; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+// UUIDv7 RFC 9562 version 7, unix milliseconds then random bits
+type UUIDv7 struct{}
+
+func (UUIDv7) NewID() string {
+	var id [16]byte
+	putMillis(id[:6], time.Now())
+	randomBytes(id[6:])
+	id[6] = id[6]&0x0f | 0x70
+	id[8] = id[8]&0x3f | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", id[0:4], id[4:6], id[6:8], id[8:10], id[10:16])
+}
+
+func putMillis(b []byte, t time.Time) {
+	ms := uint64(t.UnixMilli())
+	for i := 5; i >= 0; i-- {
+		b[i] = byte(ms)
+		ms >>= 8
+	}
+}
+
+func randomBytes(b []byte) {
+	// crypto/rand never fails on supported platforms
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,135 @@
+package idgen
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  Config
+		pattern string
+		err     string
+	}{
+		{name: "default", config: Config{}, pattern: `^[0-9a-z]{10}$`},
+		{name: "random length", config: Config{Generator: RandomGenerator, Length: 32}, pattern: `^[0-9a-z]{32}$`},
+		{name: "ulid", config: Config{Generator: ULIDGenerator}, pattern: `^[0-7][0-9A-HJKMNP-TV-Z]{25}$`},
+		{name: "uuidv7", config: Config{Generator: UUIDv7Generator}, pattern: `^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`},
+		{name: "too short", config: Config{Length: 7}, err: "length: must be between 8 and 64"},
+		{name: "too long", config: Config{Length: 65}, err: "length: must be between 8 and 64"},
+		{name: "unknown", config: Config{Generator: "snowflake"}, err: `generator: must be random, ulid or uuidv7, got "snowflake"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := New(&tt.config)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("New() error = %v, want %q", err, tt.err)
+				}
+				if err := tt.config.Validate(); err == nil || !strings.HasPrefix(err.Error(), "traceId.") {
+					t.Errorf("Validate() error = %v, want it prefixed by traceId.", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+
+			pattern := regexp.MustCompile(tt.pattern)
+			seen := map[string]bool{}
+			for i := 0; i < 100; i++ {
+				id := g.NewID()
+				if !pattern.MatchString(id) {
+					t.Fatalf("NewID() = %s, want it to match %s", id, tt.pattern)
+				}
+				if !Valid(id) {
+					t.Fatalf("NewID() = %s is not Valid", id)
+				}
+				if seen[id] {
+					t.Fatalf("NewID() = %s twice", id)
+				}
+				seen[id] = true
+			}
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"abcd1234", true},
+		{"01J9ZK3V8Q_trace-ID", true},
+		{strings.Repeat("a", 64), true},
+		{"abc1234", false},
+		{strings.Repeat("a", 65), false},
+		{"abcd 1234", false},
+		{"abcd1234\n", false},
+		{"abcd\"1234", false},
+		{"追踪标识符追踪标识符", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		if got := Valid(tt.id); got != tt.want {
+			t.Errorf("Valid(%q) = %v, want %v", tt.id, got, tt.want)
+		}
+	}
+}
+
+func TestULIDTime(t *testing.T) {
+	before := time.Now().UnixMilli()
+	id := ULID{}.NewID()
+	after := time.Now().UnixMilli()
+
+	// the first 10 chars are the 48 bits of milliseconds, 2 bits of padding first
+	var ms int64
+	for _, c := range id[:10] {
+		ms = ms<<5 | int64(strings.IndexRune(crockford, c))
+	}
+	if ms < before || ms > after {
+		t.Errorf("ULID %s has time %d, want between %d and %d", id, ms, before, after)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	if next := (ULID{}).NewID(); next <= id {
+		t.Errorf("ULID %s of a later millisecond sorts before %s", next, id)
+	}
+}
+
+func TestSetDefault(t *testing.T) {
+	defer SetDefault(Random{Length: defaultLength})
+
+	SetDefault(UUIDv7{})
+	if id := NewID(); len(id) != 36 {
+		t.Errorf("NewID() = %s, want a uuid", id)
+	}
+	// another generator type must not panic the atomic value
+	SetDefault(ULID{})
+	if id := NewID(); len(id) != 26 {
+		t.Errorf("NewID() = %s, want an ulid", id)
+	}
+}
+
+func TestRandomStringDistribution(t *testing.T) {
+	counts := map[rune]int{}
+	const n = 36 * 1000
+	for _, c := range RandomString(n) {
+		counts[c]++
+	}
+
+	if len(counts) != len(alphabet) {
+		t.Fatalf("RandomString used %d chars, want %d", len(counts), len(alphabet))
+	}
+	for c, count := range counts {
+		// 1000 expected per char, 200 off is over 6 sigma
+		if count < 800 || count > 1200 {
+			t.Errorf("char %c came %d times out of %d", c, count, n)
+		}
+	}
+}
@@ -1,5 +1,31 @@
 package logger
 
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"top-ping/pkg/masking"
+	"top-ping/pkg/utils"
+)
+
+const defaultBodyMaxSize = 8 << 10
+
+var (
+	defaultRedactHeaders = []string{"Authorization", "Cookie", "X-Api-Key"}
+	// defaultBodyContentTypes text bodies, binary, compressed and event streams are left out
+	defaultBodyContentTypes = []string{
+		"application/json",
+		"application/problem+json",
+		"application/xml",
+		"application/x-www-form-urlencoded",
+		"text/plain",
+		"text/xml",
+	}
+	statusClassRegexp = regexp.MustCompile(`^[1-5]xx$`)
+)
+
 type Config struct {
 	Level      string `mapstructure:"level"`
 	Dir        string `mapstructure:"dir"`
@@ -7,7 +33,194 @@ type Config struct {
 	MaxBackups int    `mapstructure:"maxBackups"`
 	MaxAge     int    `mapstructure:"maxAge"`
 
+	// Stdout enabled by default in the dev profile only, File enabled by default
+	Stdout   OutputConfig   `mapstructure:"stdout"`
+	File     OutputConfig   `mapstructure:"file"`
+	Sampling SamplingConfig `mapstructure:"sampling"`
+
 	SkipPaths   []string `mapstructure:"skipPaths"`
 	Desensitize bool     `mapstructure:"desensitize"`
-	SkipFields  []string `mapstructure:"skipFields"`
+	// SkipFields masking rules "pattern[:strategy[:arg]]", e.g. password, user.card:last:4, **.email:email
+	SkipFields []string `mapstructure:"skipFields"`
+
+	// RedactHeaders headers masked in access and http client logs, Authorization, Cookie and X-Api-Key when empty
+	RedactHeaders []string `mapstructure:"redactHeaders"`
+
+	Body BodyConfig `mapstructure:"body"`
+	// StatusSampling share of response logs kept per status class, e.g. 2xx: 0.1, classes not listed are all kept
+	StatusSampling map[string]float64 `mapstructure:"statusSampling"`
+
+	Gorm GormConfig `mapstructure:"gorm"`
+
+	skipPathRegexps []*regexp.Regexp
+	masker          *masking.Masker
+}
+
+// BodyConfig request and response bodies in access.log
+type BodyConfig struct {
+	// MaxSize bytes captured per body, longer ones are truncated, 8KB when 0
+	MaxSize int `mapstructure:"maxSize"`
+	// ContentTypes media types captured, bodies of other types are not logged.
+	// With desensitize on, only json and form bodies are masked and logged, the others are left out.
+	ContentTypes []string `mapstructure:"contentTypes"`
+}
+
+// GormConfig sql logs, bound params of columns matching skipFields are redacted when desensitize is on
+type GormConfig struct {
+	// Level silent, error, warn or info, warn when empty: errors and slow queries only
+	Level string `mapstructure:"level"`
+	// SlowThreshold 200ms when 0
+	SlowThreshold        time.Duration `mapstructure:"slowThreshold"`
+	IgnoreRecordNotFound bool          `mapstructure:"ignoreRecordNotFound"`
+	// ParameterizedQueries log sql with placeholders, no param is logged at all
+	ParameterizedQueries bool `mapstructure:"parameterizedQueries"`
+}
+
+// OutputConfig Encoding is json or console, json by default
+type OutputConfig struct {
+	Enabled  *bool  `mapstructure:"enabled"`
+	Encoding string `mapstructure:"encoding"`
+}
+
+// SamplingConfig log the first Initial entries with the same message per Tick, then every Thereafter-th.
+// Sampling is off when Initial is 0. It applies to default.log and stdout, not to access.log.
+type SamplingConfig struct {
+	Initial    int           `mapstructure:"initial"`
+	Thereafter int           `mapstructure:"thereafter"`
+	Tick       time.Duration `mapstructure:"tick"`
+}
+
+func (c *Config) StdoutEnabled(profile string) bool {
+	if c.Stdout.Enabled == nil {
+		return profile == devProfile
+	}
+	return *c.Stdout.Enabled
+}
+
+func (c *Config) FileEnabled() bool {
+	if c.File.Enabled == nil {
+		return true
+	}
+	return *c.File.Enabled
+}
+
+// SkipPath whether the logs of path are skipped by skipPaths
+func (c *Config) SkipPath(path string) bool {
+	for _, reg := range c.skipPathRegexps {
+		if reg.MatchString(path) {
+			return true
+		}
+	}
+	return false
+}
+
+// compile the skipPaths regexes and skipFields rules once, they are validated before
+func (c *Config) compile() {
+	c.skipPathRegexps = nil
+	for _, skipPath := range c.SkipPaths {
+		if reg, err := regexp.Compile(skipPath); err == nil {
+			c.skipPathRegexps = append(c.skipPathRegexps, reg)
+		}
+	}
+	c.masker, _ = masking.New(c.SkipFields)
+}
+
+// Masker the masking rules of skipFields, apply them when Desensitize is on
+func (c *Config) Masker() *masking.Masker {
+	if c.masker == nil {
+		masker, _ := masking.New(c.SkipFields)
+		return masker
+	}
+	return c.masker
+}
+
+// RedactHeader a copy of header to log, with the values of RedactHeaders masked
+func (c *Config) RedactHeader(header http.Header) http.Header {
+	redactHeaders := c.RedactHeaders
+	if len(redactHeaders) == 0 {
+		redactHeaders = defaultRedactHeaders
+	}
+	return utils.MaskHttpHeader(header, redactHeaders)
+}
+
+func (c *Config) BodyMaxSize() int {
+	if c.Body.MaxSize <= 0 {
+		return defaultBodyMaxSize
+	}
+	return c.Body.MaxSize
+}
+
+// BodyLogged whether bodies of contentType are captured, empty ones are
+func (c *Config) BodyLogged(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	contentTypes := c.Body.ContentTypes
+	if len(contentTypes) == 0 {
+		contentTypes = defaultBodyContentTypes
+	}
+	for _, allowed := range contentTypes {
+		if mediaType == strings.ToLower(allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+// SampleRate share of response logs kept for status
+func (c *Config) SampleRate(status int) float64 {
+	if rate, ok := c.StatusSampling[fmt.Sprintf("%dxx", status/100)]; ok {
+		return rate
+	}
+	return 1
+}
+
+func (c *Config) Validate() error {
+	if _, ok := loggerLevelMap[c.Level]; !ok && c.Level != "" {
+		return fmt.Errorf("logging.level: unknown level %q", c.Level)
+	}
+
+	for name, output := range map[string]OutputConfig{"stdout": c.Stdout, "file": c.File} {
+		if output.Encoding != "" && output.Encoding != jsonEncoding && output.Encoding != consoleEncoding {
+			return fmt.Errorf("logging.%s.encoding: must be %s or %s, got %q", name, jsonEncoding, consoleEncoding, output.Encoding)
+		}
+	}
+	if c.FileEnabled() && c.Dir == "" {
+		return fmt.Errorf("logging.dir: is required when file output is enabled")
+	}
+	if c.Sampling.Initial < 0 || c.Sampling.Thereafter < 0 {
+		return fmt.Errorf("logging.sampling: initial and thereafter must not be negative")
+	}
+
+	if _, ok := gormLevelMap[c.Gorm.Level]; !ok && c.Gorm.Level != "" {
+		return fmt.Errorf("logging.gorm.level: must be silent, error, warn or info, got %q", c.Gorm.Level)
+	}
+	if c.Gorm.SlowThreshold < 0 {
+		return fmt.Errorf("logging.gorm.slowThreshold: must not be negative")
+	}
+
+	if c.Body.MaxSize < 0 {
+		return fmt.Errorf("logging.body.maxSize: must not be negative")
+	}
+	for class, rate := range c.StatusSampling {
+		if !statusClassRegexp.MatchString(class) {
+			return fmt.Errorf("logging.statusSampling: unknown status class %q, must be like 2xx", class)
+		}
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("logging.statusSampling.%s: must be between 0 and 1, got %v", class, rate)
+		}
+	}
+
+	for _, skipPath := range c.SkipPaths {
+		if _, err := regexp.Compile(skipPath); err != nil {
+			return fmt.Errorf("logging.skipPaths: %v", err)
+		}
+	}
+	if _, err := masking.New(c.SkipFields); err != nil {
+		return fmt.Errorf("logging.skipFields: %v", err)
+	}
+
+	return nil
 }
@@ -2,46 +2,76 @@ package logger
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 	gormlogger "gorm.io/gorm/logger"
 	"time"
+	"top-ping/pkg/tracing"
 )
 
-const GormLoggerCallerSkip = 2
+const (
+	GormLoggerCallerSkip = 2
+
+	defaultSlowThreshold = 200 * time.Millisecond
+)
+
+var gormLevelMap = map[string]gormlogger.LogLevel{
+	"silent": gormlogger.Silent,
+	"error":  gormlogger.Error,
+	"warn":   gormlogger.Warn,
+	"info":   gormlogger.Info,
+}
 
 type GormLogger struct {
-	ZapLogger     *zap.Logger
-	slowThreshold time.Duration
+	ZapLogger            *zap.Logger
+	level                gormlogger.LogLevel
+	slowThreshold        time.Duration
+	ignoreRecordNotFound bool
+	parameterizedQueries bool
 }
 
 // LogMode 实现 gorm logger 接口方法
 func (g GormLogger) LogMode(gormLogLevel gormlogger.LogLevel) gormlogger.Interface {
 	newlogger := g
+	newlogger.level = gormLogLevel
 	return &newlogger
 }
 
 // Info 实现 gorm logger 接口方法
 func (g GormLogger) Info(ctx context.Context, msg string, data ...interface{}) {
+	if g.level < gormlogger.Info {
+		return
+	}
 	fmtMsg := fmt.Sprintf(msg, data...)
 	var fields []zap.Field
-	allFields := addTraceFields(ctx, fields...)
+	allFields := addContextFields(ctx, fields...)
 	g.ZapLogger.Info(fmtMsg, allFields...)
 }
 
 // Warn 实现 gorm logger 接口方法
 func (g GormLogger) Warn(ctx context.Context, msg string, data ...interface{}) {
+	if g.level < gormlogger.Warn {
+		return
+	}
 	fmtMsg := fmt.Sprintf(msg, data...)
 	var fields []zap.Field
-	allFields := addTraceFields(ctx, fields...)
+	allFields := addContextFields(ctx, fields...)
 	g.ZapLogger.Warn(fmtMsg, allFields...)
 }
 
 // Error 实现 gorm logger 接口方法
 func (g GormLogger) Error(ctx context.Context, msg string, data ...interface{}) {
+	if g.level < gormlogger.Error {
+		return
+	}
 	fmtMsg := fmt.Sprintf(msg, data...)
 	var fields []zap.Field
-	allFields := addTraceFields(ctx, fields...)
+	allFields := addContextFields(ctx, fields...)
 	g.ZapLogger.Error(fmtMsg, allFields...)
 }
 
@@ -49,6 +79,7 @@ func (g GormLogger) Error(ctx context.Context, msg string, data ...interface{})
 func (g GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (string, int64), err error) {
 	elapsed := time.Since(begin)
 	sql, rows := fc()
+	traceQuery(ctx, begin, sql, rows, err)
 
 	var fields []zap.Field
 	fields = append(fields, zap.Duration("elapsed", elapsed))
@@ -56,22 +87,67 @@ func (g GormLogger) Trace(ctx context.Context, begin time.Time, fc func() (strin
 	fields = append(fields, zap.String("sql", sql))
 
 	switch {
-	case err != nil:
+	case err != nil && g.level >= gormlogger.Error && !(g.ignoreRecordNotFound && errors.Is(err, gorm.ErrRecordNotFound)):
 		fields = append(fields, zap.String("error", err.Error()))
-		allFields := addTraceFields(ctx, fields...)
+		allFields := addContextFields(ctx, fields...)
 		g.ZapLogger.Error("SqlErrorLog", allFields...)
-	case g.slowThreshold != 0 && elapsed > g.slowThreshold:
-		allFields := addTraceFields(ctx, fields...)
+	case g.slowThreshold != 0 && elapsed > g.slowThreshold && g.level >= gormlogger.Warn:
+		allFields := addContextFields(ctx, fields...)
 		g.ZapLogger.Warn("SqlSlowLog", allFields...)
-	default:
-		allFields := addTraceFields(ctx, fields...)
+	case g.level >= gormlogger.Info:
+		allFields := addContextFields(ctx, fields...)
 		g.ZapLogger.Info("SqlInfoLog", allFields...)
 	}
 }
 
+// ParamsFilter 实现 gorm ParamsFilter 接口方法, the sql of logs and spans is built from the filtered params
+func (g GormLogger) ParamsFilter(ctx context.Context, sql string, params ...interface{}) (string, []interface{}) {
+	if g.parameterizedQueries {
+		return sql, nil
+	}
+
+	config := CurrentConfig()
+	if !config.Desensitize || len(config.SkipFields) == 0 {
+		return sql, params
+	}
+	return sql, redactParams(sql, params, config.Masker())
+}
+
+// traceQuery record the finished query as a span, it starts at begin so it covers the whole query
+func traceQuery(ctx context.Context, begin time.Time, sql string, rows int64, err error) {
+	_, span := tracing.Start(ctx, "gorm.query",
+		trace.WithTimestamp(begin),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.query.text", sql),
+			attribute.Int64("db.response.returned_rows", rows),
+		),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// NewGormLogger configured by logging.gorm of the current config
 func NewGormLogger() GormLogger {
+	config := CurrentConfig().Gorm
+
+	level, ok := gormLevelMap[config.Level]
+	if !ok {
+		level = gormlogger.Warn
+	}
+	slowThreshold := config.SlowThreshold
+	if slowThreshold == 0 {
+		slowThreshold = defaultSlowThreshold
+	}
+
 	return GormLogger{
-		ZapLogger:     logger.WithOptions(zap.AddCallerSkip(GormLoggerCallerSkip)),
-		slowThreshold: 200 * time.Millisecond,
+		ZapLogger:            logger.WithOptions(zap.AddCallerSkip(GormLoggerCallerSkip)),
+		level:                level,
+		slowThreshold:        slowThreshold,
+		ignoreRecordNotFound: config.IgnoreRecordNotFound,
+		parameterizedQueries: config.ParameterizedQueries,
 	}
 }
@@ -0,0 +1,163 @@
+package logger
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"top-ping/pkg/masking"
+)
+
+var (
+	// insertRegexp the column list of INSERT INTO t (a, b) VALUES
+	insertRegexp = regexp.MustCompile("(?is)^\\s*INSERT\\s+INTO\\s+\\S+\\s*\\(([^)]*)\\)\\s*VALUES")
+	// comparedColumnRegexp the column compared with the placeholder that follows, e.g. `t`.`password` = ? or id IN (?,?
+	comparedColumnRegexp = regexp.MustCompile("(?i)([a-z_][a-z0-9_]*)[`\"\\]]?\\s*(?:=|<>|!=|<=|>=|<|>|\\s+like|\\s+in\\s*\\([^()]*)\\s*$")
+	numericPlaceholder   = regexp.MustCompile(`^\$(\d+)`)
+)
+
+// redactParams mask the params bound to columns matching the rules, placeholders are ? or $n like gorm explains them
+func redactParams(sql string, params []interface{}, masker *masking.Masker) []interface{} {
+	redacted := make([]interface{}, len(params))
+	copy(redacted, params)
+
+	var insertColumns []string
+	valuesStart, valuesEnd := -1, -1
+	if matches := insertRegexp.FindStringSubmatchIndex(sql); matches != nil {
+		for _, column := range strings.Split(sql[matches[2]:matches[3]], ",") {
+			insertColumns = append(insertColumns, strings.Trim(strings.TrimSpace(column), "`\"[]"))
+		}
+		valuesStart = matches[1]
+		valuesEnd = valuesListEnd(sql, valuesStart)
+	}
+
+	valuesIndex := 0
+	for i, position := range placeholders(sql, len(params)) {
+		if position < 0 {
+			continue
+		}
+
+		var column string
+		if valuesStart >= 0 && position > valuesStart && position < valuesEnd {
+			// the k-th placeholder of the VALUES rows binds column k modulo the column count
+			column = insertColumns[valuesIndex%len(insertColumns)]
+			valuesIndex++
+		} else {
+			prefix := sql[:position]
+			if len(prefix) > 256 {
+				prefix = prefix[len(prefix)-256:]
+			}
+			if matches := comparedColumnRegexp.FindStringSubmatch(prefix); matches != nil {
+				column = matches[1]
+			}
+		}
+
+		if column == "" {
+			if valuesEnd >= 0 && position >= valuesEnd && !masker.Empty() {
+				// after the VALUES of an upsert, e.g. ON CONFLICT ... DO UPDATE, the column is unknown
+				redacted[i] = masking.Mask
+			}
+			continue
+		}
+		if rule, ok := masker.Match(column); ok {
+			redacted[i] = rule.Apply(params[i])
+		}
+	}
+
+	return redacted
+}
+
+// valuesListEnd the offset after the last (...) row of the VALUES list starting at start, quoted parens are skipped
+func valuesListEnd(sql string, start int) int {
+	end := start
+	position := start
+	for {
+		for position < len(sql) && (sql[position] == ' ' || sql[position] == '\t' || sql[position] == '\n' || sql[position] == '\r') {
+			position++
+		}
+		if position >= len(sql) || sql[position] != '(' {
+			return end
+		}
+
+		depth := 0
+		var quote byte
+		for ; position < len(sql); position++ {
+			c := sql[position]
+			if quote != 0 {
+				if c == quote {
+					quote = 0
+				}
+				continue
+			}
+
+			switch c {
+			case '\'', '"', '`':
+				quote = c
+			case '(':
+				depth++
+			case ')':
+				depth--
+			}
+			if depth == 0 {
+				break
+			}
+		}
+		if position >= len(sql) {
+			// unbalanced, treat the rest as values
+			return len(sql)
+		}
+		position++
+		end = position
+
+		for position < len(sql) && (sql[position] == ' ' || sql[position] == '\t' || sql[position] == '\n' || sql[position] == '\r') {
+			position++
+		}
+		if position >= len(sql) || sql[position] != ',' {
+			return end
+		}
+		position++
+	}
+}
+
+// placeholders the offset in sql of the placeholder of every param, -1 when it has none.
+// ? and $n inside quoted strings and identifiers are not placeholders.
+func placeholders(sql string, count int) []int {
+	positions := make([]int, count)
+	for i := range positions {
+		positions[i] = -1
+	}
+
+	var marks, numbered []int
+	var quote byte
+	for position := 0; position < len(sql); position++ {
+		c := sql[position]
+		if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+			continue
+		}
+
+		switch c {
+		case '\'', '"', '`':
+			quote = c
+		case '?':
+			marks = append(marks, position)
+		case '$':
+			if m := numericPlaceholder.FindStringSubmatchIndex(sql[position:]); m != nil {
+				n, _ := strconv.Atoi(sql[position+m[2] : position+m[3]])
+				if n >= 1 && n <= count {
+					positions[n-1] = position
+				}
+				numbered = append(numbered, position)
+			}
+		}
+	}
+	if len(numbered) > 0 {
+		return positions
+	}
+
+	for i := 0; i < len(marks) && i < count; i++ {
+		positions[i] = marks[i]
+	}
+	return positions
+}
@@ -0,0 +1,152 @@
+package logger
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"top-ping/pkg/masking"
+)
+
+func TestRedactParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []string
+		sql    string
+		params []interface{}
+		want   []interface{}
+	}{
+		{
+			name:   "multi-row insert",
+			rules:  []string{"password"},
+			sql:    "INSERT INTO `user` (`name`,`password`) VALUES (?,?),(?,?)",
+			params: []interface{}{"jane", "s1", "john", "s2"},
+			want:   []interface{}{"jane", masking.Mask, "john", masking.Mask},
+		},
+		{
+			name:   "on conflict upsert",
+			rules:  []string{"password"},
+			sql:    `INSERT INTO "user" ("name","password") VALUES ($1,$2) ON CONFLICT ("id") DO UPDATE SET "password"=$3,"name"=$4`,
+			params: []interface{}{"jane", "s1", "s1", "jane"},
+			want:   []interface{}{"jane", masking.Mask, masking.Mask, "jane"},
+		},
+		{
+			name:   "on duplicate key upsert",
+			rules:  []string{"password"},
+			sql:    "INSERT INTO `user` (`name`,`password`) VALUES (?,?) ON DUPLICATE KEY UPDATE `password`=?,`logins`=`logins`+?",
+			params: []interface{}{"jane", "s1", "s1", 1},
+			// the column of an expression is unknown, masked to be safe
+			want: []interface{}{"jane", masking.Mask, masking.Mask, masking.Mask},
+		},
+		{
+			name:   "numbered placeholders out of order",
+			rules:  []string{"token:last:2"},
+			sql:    `SELECT * FROM "session" WHERE "user_id" = $2 AND "token" = $1`,
+			params: []interface{}{"abcdef", 7},
+			want:   []interface{}{"***ef", 7},
+		},
+		{
+			name:   "in list",
+			rules:  []string{"token"},
+			sql:    "SELECT * FROM `session` WHERE `token` IN (?,?,?) AND `user_id` = ?",
+			params: []interface{}{"a", "b", "c", 7},
+			want:   []interface{}{masking.Mask, masking.Mask, masking.Mask, 7},
+		},
+		{
+			name:   "quoted parens and question marks",
+			rules:  []string{"password"},
+			sql:    "SELECT * FROM `user` WHERE `note` = 'why (?)' AND `password` = ? AND `name` = ?",
+			params: []interface{}{"s1", "jane"},
+			want:   []interface{}{masking.Mask, "jane"},
+		},
+		{
+			name:   "no sensitive column",
+			rules:  []string{"password"},
+			sql:    "INSERT INTO `user` (`name`,`age`) VALUES (?,?) ON DUPLICATE KEY UPDATE `age`=`age`+?",
+			params: []interface{}{"jane", 30, 1},
+			// the column after VALUES is unknown but the masker has a rule, masked
+			want: []interface{}{"jane", 30, masking.Mask},
+		},
+		{
+			name:   "no rules",
+			sql:    "INSERT INTO `user` (`name`,`password`) VALUES (?,?) ON DUPLICATE KEY UPDATE `logins`=`logins`+?",
+			params: []interface{}{"jane", "s1", 1},
+			want:   []interface{}{"jane", "s1", 1},
+		},
+		{
+			name:   "select passes through",
+			rules:  []string{"password"},
+			sql:    "SELECT * FROM `user` WHERE `name` = ? LIMIT ?",
+			params: []interface{}{"jane", 1},
+			want:   []interface{}{"jane", 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			masker, err := masking.New(tt.rules)
+			if err != nil {
+				t.Fatalf("masking.New() error = %v", err)
+			}
+			params := append([]interface{}{}, tt.params...)
+			got := redactParams(tt.sql, tt.params, masker)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("redactParams() = %v, want %v", got, tt.want)
+			}
+			if !reflect.DeepEqual(tt.params, params) {
+				t.Error("redactParams() changed the params bound to the statement")
+			}
+		})
+	}
+}
+
+func TestValuesListEnd(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		// rest the sql after the end of the list
+		rest string
+	}{
+		{name: "one row", sql: "INSERT INTO t (a) VALUES (?)", rest: ""},
+		{name: "rows", sql: "INSERT INTO t (a,b) VALUES (?,?), (?,?)\n,(?,?) RETURNING id", rest: " RETURNING id"},
+		{name: "nested parens", sql: "INSERT INTO t (a) VALUES (lower(?)),(?) ON CONFLICT (a) DO NOTHING", rest: " ON CONFLICT (a) DO NOTHING"},
+		{name: "quoted parens", sql: "INSERT INTO t (a,b) VALUES ('x)',?),(')(', ?) ON DUPLICATE KEY UPDATE b=?", rest: " ON DUPLICATE KEY UPDATE b=?"},
+		{name: "no list", sql: "INSERT INTO t (a) VALUES DEFAULT", rest: " DEFAULT"},
+		{name: "unbalanced", sql: "INSERT INTO t (a) VALUES (?, (?)", rest: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start := strings.Index(tt.sql, "VALUES") + len("VALUES")
+			if end := valuesListEnd(tt.sql, start); tt.sql[end:] != tt.rest {
+				t.Errorf("valuesListEnd() rest = %q, want %q", tt.sql[end:], tt.rest)
+			}
+		})
+	}
+}
+
+func TestPlaceholders(t *testing.T) {
+	tests := []struct {
+		name  string
+		sql   string
+		count int
+		want  []int
+	}{
+		{name: "question marks", sql: "a = ? and b = ?", count: 2, want: []int{4, 14}},
+		{name: "numbered", sql: "a = $2 and b = $1", count: 2, want: []int{15, 4}},
+		{name: "repeated number", sql: "a = $1 or b = $1", count: 1, want: []int{14}},
+		{name: "fewer placeholders", sql: "a = ?", count: 2, want: []int{4, -1}},
+		{name: "number out of range", sql: "a = $3", count: 2, want: []int{-1, -1}},
+		{name: "quoted question mark", sql: "a = '?' and b = ?", count: 1, want: []int{16}},
+		{name: "quoted number", sql: `a = '$1' and "b$1" = $1`, count: 1, want: []int{21}},
+		{name: "quoted identifier", sql: "`a?` = ?", count: 1, want: []int{7}},
+		{name: "escaped quote", sql: "a = 'it''s ?' and b = ?", count: 1, want: []int{22}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := placeholders(tt.sql, tt.count); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("placeholders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"fmt"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"sync"
+	"time"
+)
+
+// atomicLevel level of the default core, it can be changed at runtime
+var atomicLevel = zap.NewAtomicLevel()
+
+var (
+	levelMu sync.Mutex
+	// configLevel the level from the config, restored when an override expires
+	configLevel   zapcore.Level
+	overridden    bool
+	overrideUntil time.Time
+	revertTimer   *time.Timer
+	// overrideGen bumped by every override and reset, a revert timer of an older generation does nothing
+	overrideGen uint64
+)
+
+type LevelStatus struct {
+	Level       string     `json:"level"`
+	ConfigLevel string     `json:"configLevel"`
+	Overridden  bool       `json:"overridden"`
+	ExpiresAt   *time.Time `json:"expiresAt,omitempty"`
+}
+
+// SetLevel change the configured level of default.log, error.log always keeps error and above.
+// An empty level means debug, the same as at startup. An active override stays in effect.
+func SetLevel(level string) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	configLevel = lvl
+	if !overridden {
+		atomicLevel.SetLevel(lvl)
+	}
+	return nil
+}
+
+// OverrideLevel change the level temporarily, it reverts to the configured level after ttl, or never when ttl is 0
+func OverrideLevel(level string, ttl time.Duration) error {
+	lvl, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	stopRevertTimer()
+	overrideGen++
+	overridden = true
+	overrideUntil = time.Time{}
+	atomicLevel.SetLevel(lvl)
+
+	if ttl > 0 {
+		gen := overrideGen
+		overrideUntil = time.Now().Add(ttl)
+		revertTimer = time.AfterFunc(ttl, func() {
+			expireOverride(gen)
+		})
+	}
+	return nil
+}
+
+// ResetLevel drop the override and go back to the configured level
+func ResetLevel() {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	resetLevel()
+}
+
+// expireOverride revert the override of generation gen, a newer override may already have replaced it
+// while the timer callback was waiting for the lock
+func expireOverride(gen uint64) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	if gen != overrideGen {
+		return
+	}
+	resetLevel()
+}
+
+func resetLevel() {
+	stopRevertTimer()
+	overrideGen++
+	overridden = false
+	overrideUntil = time.Time{}
+	atomicLevel.SetLevel(configLevel)
+}
+
+func Level() string {
+	return atomicLevel.Level().String()
+}
+
+func GetLevelStatus() LevelStatus {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	status := LevelStatus{
+		Level:       atomicLevel.Level().String(),
+		ConfigLevel: configLevel.String(),
+		Overridden:  overridden,
+	}
+	if !overrideUntil.IsZero() {
+		expiresAt := overrideUntil
+		status.ExpiresAt = &expiresAt
+	}
+
+	return status
+}
+
+func initLevel(level string) {
+	levelMu.Lock()
+	defer levelMu.Unlock()
+
+	configLevel = getLoggerLevel(level)
+	atomicLevel.SetLevel(configLevel)
+}
+
+func parseLevel(level string) (zapcore.Level, error) {
+	if _, ok := loggerLevelMap[level]; !ok && level != "" {
+		return zapcore.DebugLevel, fmt.Errorf("unknown log level %q", level)
+	}
+
+	return getLoggerLevel(level), nil
+}
+
+func stopRevertTimer() {
+	if revertTimer != nil {
+		revertTimer.Stop()
+		revertTimer = nil
+	}
+}
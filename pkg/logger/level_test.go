@@ -0,0 +1,146 @@
+package logger
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverrideLevel(t *testing.T) {
+	const ttl = 50 * time.Millisecond
+	tests := []struct {
+		name string
+		run  func(t *testing.T)
+		// want the level and override state after the revert timers had their time
+		want       string
+		overridden bool
+	}{
+		{
+			name: "reverts after the ttl",
+			run: func(t *testing.T) {
+				mustOverride(t, "debug", ttl)
+				if status := GetLevelStatus(); status.Level != "debug" || !status.Overridden || status.ExpiresAt == nil {
+					t.Errorf("status %+v, want a debug override with an expiry", status)
+				}
+			},
+			want: "info",
+		},
+		{
+			name: "without ttl never reverts",
+			run: func(t *testing.T) {
+				mustOverride(t, "debug", 0)
+				if status := GetLevelStatus(); status.ExpiresAt != nil {
+					t.Errorf("expires at %v, want never", status.ExpiresAt)
+				}
+			},
+			want:       "debug",
+			overridden: true,
+		},
+		{
+			name: "second override cancels the first timer",
+			run: func(t *testing.T) {
+				mustOverride(t, "debug", ttl)
+				mustOverride(t, "warn", 0)
+			},
+			want:       "warn",
+			overridden: true,
+		},
+		{
+			name: "second override with a longer ttl",
+			run: func(t *testing.T) {
+				mustOverride(t, "debug", ttl)
+				mustOverride(t, "warn", time.Minute)
+			},
+			want:       "warn",
+			overridden: true,
+		},
+		{
+			name: "reset during a pending ttl",
+			run: func(t *testing.T) {
+				mustOverride(t, "debug", ttl)
+				ResetLevel()
+				if status := GetLevelStatus(); status.Level != "info" || status.Overridden || status.ExpiresAt != nil {
+					t.Errorf("status %+v after reset, want info without override", status)
+				}
+				// the timer of the dropped override must not end this one
+				mustOverride(t, "warn", 0)
+			},
+			want:       "warn",
+			overridden: true,
+		},
+		{
+			name: "config reload keeps the override",
+			run: func(t *testing.T) {
+				mustOverride(t, "debug", 0)
+				if err := SetLevel("error"); err != nil {
+					t.Fatal(err)
+				}
+				if status := GetLevelStatus(); status.Level != "debug" || status.ConfigLevel != "error" {
+					t.Errorf("status %+v after reload, want debug overriding error", status)
+				}
+			},
+			want:       "debug",
+			overridden: true,
+		},
+		{
+			name: "override reverts to the reloaded level",
+			run: func(t *testing.T) {
+				mustOverride(t, "debug", ttl)
+				if err := SetLevel("error"); err != nil {
+					t.Fatal(err)
+				}
+			},
+			want: "error",
+		},
+		{
+			name: "expiry of an older generation",
+			run: func(t *testing.T) {
+				mustOverride(t, "debug", 0)
+				levelMu.Lock()
+				gen := overrideGen
+				levelMu.Unlock()
+
+				// a timer firing while the next override waited for the lock
+				mustOverride(t, "warn", 0)
+				expireOverride(gen)
+			},
+			want:       "warn",
+			overridden: true,
+		},
+		{
+			name: "invalid level",
+			run: func(t *testing.T) {
+				if err := OverrideLevel("loud", ttl); err == nil {
+					t.Error("OverrideLevel() of an unknown level, want an error")
+				}
+			},
+			want: "info",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := SetLevel("info"); err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(ResetLevel)
+
+			tt.run(t)
+			time.Sleep(4 * ttl)
+
+			status := GetLevelStatus()
+			if status.Level != tt.want || status.Overridden != tt.overridden {
+				t.Errorf("level %s, overridden %v, want %s, %v", status.Level, status.Overridden, tt.want, tt.overridden)
+			}
+			if Level() != status.Level {
+				t.Errorf("Level() = %s, status level %s", Level(), status.Level)
+			}
+		})
+	}
+}
+
+func mustOverride(t *testing.T, level string, ttl time.Duration) {
+	t.Helper()
+	if err := OverrideLevel(level, ttl); err != nil {
+		t.Fatalf("OverrideLevel(%q) error = %v", level, err)
+	}
+}
@@ -5,116 +5,188 @@ import (
 	"fmt"
 	"go.uber.org/zap"
 	"sync"
+	"sync/atomic"
 	"top-ping/pkg/utils"
 )
 
 var (
-	logger   *zap.Logger
-	initOnce sync.Once
+	logger       *zap.Logger
+	accessLogger *zap.Logger
+	current      atomic.Value
+	initOnce     sync.Once
 )
 
 func Init(profile string, config *Config) {
 	initOnce.Do(func() {
+		config.compile()
+		current.Store(config)
 		logger = NewZapLogger(profile, config)
+		accessLogger = NewAccessZapLogger(profile, config)
 	})
 }
 
+// CurrentConfig the logging config in effect, it changes when the config is reloaded
+func CurrentConfig() *Config {
+	if config, ok := current.Load().(*Config); ok {
+		return config
+	}
+	return &Config{}
+}
+
+// UpdateConfig apply the reloadable parts of config: level, skipPaths, skipFields, desensitize, redactHeaders,
+// body and statusSampling.
+// Outputs and rotation stay as they were at startup.
+func UpdateConfig(config *Config) error {
+	if err := SetLevel(config.Level); err != nil {
+		return err
+	}
+
+	updated := *CurrentConfig()
+	updated.Level = config.Level
+	updated.SkipPaths = config.SkipPaths
+	updated.SkipFields = config.SkipFields
+	updated.Desensitize = config.Desensitize
+	updated.RedactHeaders = config.RedactHeaders
+	updated.Body = config.Body
+	updated.StatusSampling = config.StatusSampling
+	updated.compile()
+	current.Store(&updated)
+
+	return nil
+}
+
 func Sync() {
 	if logger == nil {
 		return
 	}
 	_ = logger.Sync()
+	_ = accessLogger.Sync()
+}
+
+// Access write access and response logs to access.log
+func Access(c context.Context, msg string, fields ...zap.Field) {
+	allFields := addContextFields(c, fields...)
+	accessLogger.Info(msg, allFields...)
 }
 
 func Debug(c context.Context, msg string, fields ...zap.Field) {
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Debug(msg, allFields...)
 }
 
 func Debugf(c context.Context, format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	var fields []zap.Field
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Debug(msg, allFields...)
 }
 
 func Info(c context.Context, msg string, fields ...zap.Field) {
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Info(msg, allFields...)
 }
 
 func Infof(c context.Context, format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	var fields []zap.Field
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Info(msg, allFields...)
 }
 
 func Warn(c context.Context, msg string, fields ...zap.Field) {
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Warn(msg, allFields...)
 }
 
 func Warnf(c context.Context, format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	var fields []zap.Field
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Warn(msg, allFields...)
 }
 
 func Error(c context.Context, msg string, fields ...zap.Field) {
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Error(msg, allFields...)
 }
 
 func Errorf(c context.Context, format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	var fields []zap.Field
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Error(msg, allFields...)
 }
 
 func Panic(c context.Context, msg string, fields ...zap.Field) {
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Panic(msg, allFields...)
 }
 
 func Panicf(c context.Context, format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	var fields []zap.Field
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Panic(msg, allFields...)
 }
 
 func Fatal(c context.Context, msg string, fields ...zap.Field) {
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Fatal(msg, allFields...)
 }
 
 func Fatalf(c context.Context, format string, args ...interface{}) {
 	msg := fmt.Sprintf(format, args...)
 	var fields []zap.Field
-	allFields := addTraceFields(c, fields...)
+	allFields := addContextFields(c, fields...)
 	logger.Fatal(msg, allFields...)
 }
 
+type fieldsKey struct{}
+
 func WithTrace(c context.Context, traceID string) context.Context {
 	return context.WithValue(c, utils.TraceKey, traceID)
 }
 
-func addTraceFields(c context.Context, fields ...zap.Field) []zap.Field {
+// WithFields attach fields to the context, every log line written with it carries them,
+// e.g. the user id, target id or route of the request
+func WithFields(c context.Context, fields ...zap.Field) context.Context {
+	if len(fields) == 0 {
+		return c
+	}
+
+	existing := contextFields(c)
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	merged = append(merged, existing...)
+	merged = append(merged, fields...)
+
+	return context.WithValue(c, fieldsKey{}, merged)
+}
+
+func contextFields(c context.Context) []zap.Field {
+	if c == nil {
+		return nil
+	}
+	if fields, ok := c.Value(fieldsKey{}).([]zap.Field); ok {
+		return fields
+	}
+	return nil
+}
+
+// addContextFields the caller's fields followed by the trace id and the fields attached with WithFields
+func addContextFields(c context.Context, fields ...zap.Field) []zap.Field {
+	if c == nil {
+		return fields
+	}
+
+	ctxFields := contextFields(c)
+	allFields := make([]zap.Field, 0, len(fields)+len(ctxFields)+1)
+	allFields = append(allFields, fields...)
 	if v := c.Value(utils.TraceKey); v != nil {
 		if t, ok := v.(string); ok {
-			var allFields []zap.Field
-			if fields != nil {
-				allFields = append(fields, zap.String(utils.TraceKey, t))
-			} else {
-				allFields = append(allFields, zap.String(utils.TraceKey, t))
-			}
-
-			return allFields
+			allFields = append(allFields, zap.String(utils.TraceKey, t))
 		}
 	}
+	allFields = append(allFields, ctxFields...)
 
-	return nil
+	return allFields
 }
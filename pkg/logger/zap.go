@@ -5,6 +5,8 @@ import (
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 	"os"
+	"path/filepath"
+	"time"
 	"top-ping/pkg/utils"
 )
 
@@ -12,6 +14,10 @@ const (
 	devProfile  = "dev"
 	defaultFile = "default.log"
 	errorFile   = "error.log"
+	accessFile  = "access.log"
+
+	jsonEncoding    = "json"
+	consoleEncoding = "console"
 )
 
 var loggerLevelMap = map[string]zapcore.Level{
@@ -25,8 +31,10 @@ var loggerLevelMap = map[string]zapcore.Level{
 }
 
 func NewZapLogger(profile string, config *Config) *zap.Logger {
-	if ok, _ := utils.PathExists(config.Dir); !ok {
-		_ = os.Mkdir(config.Dir, os.ModePerm)
+	if config.FileEnabled() {
+		if ok, _ := utils.PathExists(config.Dir); !ok {
+			_ = os.Mkdir(config.Dir, os.ModePerm)
+		}
 	}
 
 	var cores []zapcore.Core
@@ -43,57 +51,71 @@ func NewZapLogger(profile string, config *Config) *zap.Logger {
 	return zap.New(combinedCore, options...)
 }
 
-func newDefaultCore(profile string, config *Config) zapcore.Core {
-	defaultWriter := newLogWriter(config.Dir+"/"+defaultFile, config)
-	configLogLevel := getLoggerLevel(config.Level)
-	defaultLevel := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
-		return configLogLevel <= lvl && lvl <= zapcore.FatalLevel
+// NewAccessZapLogger logger of the access and response logs, written to access.log instead of default.log
+func NewAccessZapLogger(profile string, config *Config) *zap.Logger {
+	accessLevel := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
+		return lvl >= zapcore.InfoLevel
 	})
 
-	encoderCfg := newEncoderConfig(profile)
-	var core zapcore.Core
-	var writeSyncer zapcore.WriteSyncer
-	if profile == devProfile {
-		writeSyncer = zapcore.NewMultiWriteSyncer(
-			zapcore.AddSync(os.Stdout),
-			zapcore.AddSync(defaultWriter))
-	} else {
-		writeSyncer = zapcore.AddSync(defaultWriter)
-	}
+	core := newCore(profile, config, accessFile, accessLevel, config.StdoutEnabled(profile))
 
-	core = zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderCfg),
-		writeSyncer,
-		defaultLevel,
-	)
+	var options []zap.Option
+	options = append(options, zap.AddCaller())
+	options = append(options, zap.AddCallerSkip(1))
+
+	// not sampled: all access lines share two messages, so sampling would drop nearly all of them.
+	// logging.statusSampling thins out response logs instead.
+	return zap.New(core, options...)
+}
 
-	return core
+func newDefaultCore(profile string, config *Config) zapcore.Core {
+	initLevel(config.Level)
+	core := newCore(profile, config, defaultFile, atomicLevel, config.StdoutEnabled(profile))
+
+	return sample(core, config)
 }
 
+// newErrorCore error.log only, the default core already writes errors to stdout
 func newErrorCore(profile string, config *Config) zapcore.Core {
-	errorWriter := newLogWriter(config.Dir+"/"+errorFile, config)
 	errorLevel := zap.LevelEnablerFunc(func(lvl zapcore.Level) bool {
 		return lvl >= zapcore.ErrorLevel
 	})
 
-	encoderCfg := newEncoderConfig(profile)
-	var core zapcore.Core
-	var writeSyncer zapcore.WriteSyncer
-	if profile == devProfile {
-		writeSyncer = zapcore.NewMultiWriteSyncer(
-			zapcore.AddSync(os.Stdout),
-			zapcore.AddSync(errorWriter))
-	} else {
-		writeSyncer = zapcore.AddSync(errorWriter)
+	return newCore(profile, config, errorFile, errorLevel, false)
+}
+
+func newCore(profile string, config *Config, filename string, enabler zapcore.LevelEnabler, stdout bool) zapcore.Core {
+	var cores []zapcore.Core
+	if stdout {
+		cores = append(cores, zapcore.NewCore(
+			newEncoder(profile, config.Stdout.Encoding),
+			zapcore.Lock(zapcore.AddSync(os.Stdout)),
+			enabler,
+		))
+	}
+	if config.FileEnabled() {
+		cores = append(cores, zapcore.NewCore(
+			newEncoder(profile, config.File.Encoding),
+			newLogWriter(filepath.Join(config.Dir, filename), config),
+			enabler,
+		))
 	}
 
-	core = zapcore.NewCore(
-		zapcore.NewJSONEncoder(encoderCfg),
-		writeSyncer,
-		errorLevel,
-	)
+	return zapcore.NewTee(cores...)
+}
+
+// sample drop repeated messages on hot paths, error.log is never sampled
+func sample(core zapcore.Core, config *Config) zapcore.Core {
+	sampling := config.Sampling
+	if sampling.Initial <= 0 {
+		return core
+	}
 
-	return core
+	tick := sampling.Tick
+	if tick <= 0 {
+		tick = time.Second
+	}
+	return zapcore.NewSamplerWithOptions(core, tick, sampling.Initial, sampling.Thereafter)
 }
 
 func newLogWriter(filename string, config *Config) zapcore.WriteSyncer {
@@ -107,6 +129,13 @@ func newLogWriter(filename string, config *Config) zapcore.WriteSyncer {
 	return zapcore.AddSync(lumberJackLogger)
 }
 
+func newEncoder(profile string, encoding string) zapcore.Encoder {
+	if encoding == consoleEncoding {
+		return zapcore.NewConsoleEncoder(newEncoderConfig(profile))
+	}
+	return zapcore.NewJSONEncoder(newEncoderConfig(profile))
+}
+
 func newEncoderConfig(profile string) zapcore.EncoderConfig {
 	var encoderCfg zapcore.EncoderConfig
 	if profile == devProfile {
@@ -0,0 +1,146 @@
+package masking
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Masker masks values of keys matching its rules, the first matching rule wins
+type Masker struct {
+	rules []Rule
+}
+
+// New parse specs of logging.skipFields, see Rule
+func New(specs []string) (*Masker, error) {
+	m := &Masker{}
+	var errs []error
+	for _, spec := range specs {
+		rule, err := ParseRule(spec)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		m.rules = append(m.rules, rule)
+	}
+
+	return m, errors.Join(errs...)
+}
+
+// Empty whether there is no rule
+func (m *Masker) Empty() bool {
+	return m == nil || len(m.rules) == 0
+}
+
+// Match the rule of a single name, e.g. a db column or a header
+func (m *Masker) Match(name string) (*Rule, bool) {
+	return m.match([]string{name})
+}
+
+func (m *Masker) match(keyPath []string) (*Rule, bool) {
+	if m == nil {
+		return nil, false
+	}
+	for i := range m.rules {
+		if m.rules[i].matches(keyPath) {
+			return &m.rules[i], true
+		}
+	}
+	return nil, false
+}
+
+// MaskJSON mask a json document, anything else is returned as it is
+func (m *Masker) MaskJSON(s string) string {
+	if m.Empty() || !json.Valid([]byte(s)) {
+		return s
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader([]byte(s)))
+	// keep big numbers as they are
+	decoder.UseNumber()
+	var v interface{}
+	if err := decoder.Decode(&v); err != nil {
+		return s
+	}
+
+	masked, err := json.Marshal(m.Mask(v))
+	if err != nil {
+		return s
+	}
+	return string(masked)
+}
+
+// MaskForm mask the values of an application/x-www-form-urlencoded body by their key, the order of the pairs is kept
+func (m *Masker) MaskForm(s string) (string, error) {
+	if _, err := url.ParseQuery(s); err != nil {
+		return "", err
+	}
+	if m.Empty() {
+		return s, nil
+	}
+
+	pairs := strings.Split(s, "&")
+	for i, pair := range pairs {
+		key, value, _ := strings.Cut(pair, "=")
+		name, _ := url.QueryUnescape(key)
+		if rule, ok := m.Match(name); ok {
+			unescaped, _ := url.QueryUnescape(value)
+			pairs[i] = key + "=" + fmt.Sprint(rule.Apply(unescaped))
+		}
+	}
+	return strings.Join(pairs, "&"), nil
+}
+
+// MaskStruct mask the json of src
+func (m *Masker) MaskStruct(src interface{}) string {
+	jsonBytes, err := json.Marshal(src)
+	if err != nil {
+		return ""
+	}
+	return m.MaskJSON(string(jsonBytes))
+}
+
+// Mask mask a decoded json value in place, objects in arrays included
+func (m *Masker) Mask(v interface{}) interface{} {
+	return m.mask(nil, v)
+}
+
+func (m *Masker) mask(keyPath []string, v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, value := range vv {
+			childPath := append(keyPath[:len(keyPath):len(keyPath)], k)
+			if rule, ok := m.match(childPath); ok {
+				vv[k] = maskTree(rule, value)
+				continue
+			}
+			vv[k] = m.mask(childPath, value)
+		}
+	case []interface{}:
+		// arrays add no segment, items.token matches {"items": [{"token": ...}]}
+		for i, item := range vv {
+			vv[i] = m.mask(keyPath, item)
+		}
+	}
+	return v
+}
+
+// maskTree mask every leaf of a matched value
+func maskTree(rule *Rule, v interface{}) interface{} {
+	switch vv := v.(type) {
+	case map[string]interface{}:
+		for k, value := range vv {
+			vv[k] = maskTree(rule, value)
+		}
+		return vv
+	case []interface{}:
+		for i, item := range vv {
+			vv[i] = maskTree(rule, item)
+		}
+		return vv
+	}
+	return rule.Apply(v)
+}
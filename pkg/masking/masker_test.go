@@ -0,0 +1,125 @@
+package masking
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	m, err := New([]string{"password", "card:blur", "token:last"})
+	if err == nil || !strings.Contains(err.Error(), "blur") || !strings.Contains(err.Error(), "last needs") {
+		t.Fatalf("New() error = %v, want both invalid rules", err)
+	}
+	if m.Empty() {
+		t.Error("New() dropped the valid rule")
+	}
+
+	var nilMasker *Masker
+	if !nilMasker.Empty() {
+		t.Error("nil masker is not empty")
+	}
+	if _, ok := nilMasker.Match("password"); ok {
+		t.Error("nil masker matched")
+	}
+}
+
+func TestMaskJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules []string
+		in    string
+		want  string
+	}{
+		{
+			name:  "top level",
+			rules: []string{"password"},
+			in:    `{"name":"jane","password":"secret"}`,
+			want:  `{"name":"jane","password":"***"}`,
+		},
+		{
+			name:  "nested objects and arrays",
+			rules: []string{"items.token:last:2"},
+			in:    `{"items":[{"token":"abcdef"},{"token":"xyz"}],"token":"kept"}`,
+			want:  `{"items":[{"token":"***ef"},{"token":"***yz"}],"token":"kept"}`,
+		},
+		{
+			name:  "matched object masks every leaf",
+			rules: []string{"card"},
+			in:    `{"card":{"no":"6222","cvv":123}}`,
+			want:  `{"card":{"cvv":"***","no":"***"}}`,
+		},
+		{
+			name:  "any depth",
+			rules: []string{"**.email:email"},
+			in:    `{"email":"a@x.io","buyer":{"email":"b@y.io"}}`,
+			want:  `{"buyer":{"email":"***@y.io"},"email":"***@x.io"}`,
+		},
+		{
+			name:  "first matching rule wins",
+			rules: []string{"card:last:4", "card"},
+			in:    `{"card":"6222020012345678"}`,
+			want:  `{"card":"***5678"}`,
+		},
+		{
+			name:  "big numbers are kept",
+			rules: []string{"password"},
+			in:    `{"id":12345678901234567890,"password":1}`,
+			want:  `{"id":12345678901234567890,"password":"***"}`,
+		},
+		{
+			name:  "not json",
+			rules: []string{"password"},
+			in:    `password=secret`,
+			want:  `password=secret`,
+		},
+		{
+			name: "no rules",
+			in:   `{"password":"secret"}`,
+			want: `{"password":"secret"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.rules)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.MaskJSON(tt.in); got != tt.want {
+				t.Errorf("MaskJSON() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMaskForm(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+		err  bool
+	}{
+		{name: "masked by key", in: "name=a+b&password=s%26x", want: "name=a+b&password=***"},
+		{name: "escaped key", in: "pass%77ord=x&card=6222020012345678", want: "pass%77ord=***&card=***5678"},
+		{name: "repeated keys", in: "password=a&password=b", want: "password=***&password=***"},
+		{name: "key only", in: "password&x=1", want: "password=***&x=1"},
+		{name: "empty", in: "", want: ""},
+		{name: "invalid escape", in: "a=%zz", err: true},
+	}
+
+	m, err := New([]string{"password", "card:last:4"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.MaskForm(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("MaskForm() error = %v, want error %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("MaskForm() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package masking
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	StrategyFull  = "full"
+	StrategyLast  = "last"
+	StrategyHash  = "hash"
+	StrategyEmail = "email"
+
+	Mask = "***"
+)
+
+// Rule a parsed "pattern[:strategy[:arg]]" spec, e.g. "password", "user.card:last:4", "**.email:email".
+// A pattern without dots and globs matches any key containing it, case-insensitively.
+// Otherwise it matches the dotted path of the key, arrays add no segment, * matches one segment
+// and a leading ** any number of them.
+type Rule struct {
+	Pattern  string
+	Strategy string
+	// Keep chars kept by the last strategy
+	Keep int
+
+	byPath   bool
+	anyDepth bool
+	glob     string
+}
+
+func ParseRule(spec string) (Rule, error) {
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	r := Rule{Pattern: parts[0], Strategy: StrategyFull}
+	if r.Pattern == "" {
+		return r, fmt.Errorf("masking rule %q: pattern is empty", spec)
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		r.Strategy = parts[1]
+	}
+
+	switch r.Strategy {
+	case StrategyFull, StrategyHash, StrategyEmail:
+		if len(parts) > 2 {
+			return r, fmt.Errorf("masking rule %q: %s takes no argument", spec, r.Strategy)
+		}
+	case StrategyLast:
+		if len(parts) != 3 {
+			return r, fmt.Errorf("masking rule %q: last needs the number of chars to keep, e.g. last:4", spec)
+		}
+		keep, err := strconv.Atoi(parts[2])
+		if err != nil || keep < 0 {
+			return r, fmt.Errorf("masking rule %q: invalid number of chars %q", spec, parts[2])
+		}
+		r.Keep = keep
+	default:
+		return r, fmt.Errorf("masking rule %q: unknown strategy %q, must be %s, %s, %s or %s",
+			spec, r.Strategy, StrategyFull, StrategyLast, StrategyHash, StrategyEmail)
+	}
+
+	if strings.ContainsAny(r.Pattern, ".*?[") {
+		r.byPath = true
+		glob := strings.ToLower(r.Pattern)
+		if strings.HasPrefix(glob, "**.") {
+			r.anyDepth = true
+			glob = strings.TrimPrefix(glob, "**.")
+		}
+		r.glob = strings.ReplaceAll(glob, ".", "/")
+		if _, err := path.Match(r.glob, ""); err != nil {
+			return r, fmt.Errorf("masking rule %q: %v", spec, err)
+		}
+	}
+
+	return r, nil
+}
+
+// matches keyPath segments of the key, the key last
+func (r *Rule) matches(keyPath []string) bool {
+	if len(keyPath) == 0 {
+		return false
+	}
+	if !r.byPath {
+		return strings.Contains(strings.ToLower(keyPath[len(keyPath)-1]), strings.ToLower(r.Pattern))
+	}
+
+	full := strings.ToLower(strings.Join(keyPath, "/"))
+	if ok, _ := path.Match(r.glob, full); ok {
+		return true
+	}
+	if !r.anyDepth {
+		return false
+	}
+	for i := 1; i < len(keyPath); i++ {
+		if ok, _ := path.Match(r.glob, strings.ToLower(strings.Join(keyPath[i:], "/"))); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply mask a value, nil stays nil
+func (r *Rule) Apply(value interface{}) interface{} {
+	if value == nil {
+		return nil
+	}
+
+	s, ok := value.(string)
+	if !ok {
+		s = fmt.Sprint(value)
+	}
+
+	switch r.Strategy {
+	case StrategyLast:
+		runes := []rune(s)
+		if len(runes) <= r.Keep {
+			return Mask
+		}
+		return Mask + string(runes[len(runes)-r.Keep:])
+	case StrategyHash:
+		sum := sha256.Sum256([]byte(s))
+		return "sha256:" + hex.EncodeToString(sum[:])[:16]
+	case StrategyEmail:
+		if at := strings.LastIndex(s, "@"); at >= 0 {
+			return Mask + s[at:]
+		}
+		return Mask
+	}
+	return Mask
+}
@@ -0,0 +1,108 @@
+package masking
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseRule(t *testing.T) {
+	tests := []struct {
+		spec     string
+		strategy string
+		keep     int
+		err      string
+	}{
+		{spec: "password", strategy: StrategyFull},
+		{spec: " token ", strategy: StrategyFull},
+		{spec: "card:last:4", strategy: StrategyLast, keep: 4},
+		{spec: "user.id:hash", strategy: StrategyHash},
+		{spec: "**.email:email", strategy: StrategyEmail},
+		{spec: "", err: "pattern is empty"},
+		{spec: "card:last", err: "needs the number of chars"},
+		{spec: "card:last:-1", err: "invalid number of chars"},
+		{spec: "password:full:1", err: "takes no argument"},
+		{spec: "password:blur", err: "unknown strategy"},
+		{spec: "user.[", err: "syntax error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			r, err := ParseRule(tt.spec)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("ParseRule(%q) error = %v, want %q", tt.spec, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRule(%q) error = %v", tt.spec, err)
+			}
+			if r.Strategy != tt.strategy || r.Keep != tt.keep {
+				t.Errorf("ParseRule(%q) = %s:%d, want %s:%d", tt.spec, r.Strategy, r.Keep, tt.strategy, tt.keep)
+			}
+		})
+	}
+}
+
+func TestRuleMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		keyPath string
+		want    bool
+	}{
+		{"password", "password", true},
+		{"password", "user.oldPassword", true},
+		{"PASSWORD", "Password", true},
+		{"password", "name", false},
+		{"user.card", "user.card", true},
+		{"user.card", "card", false},
+		{"user.card", "order.user.card", false},
+		{"user.*", "user.phone", true},
+		{"user.*", "user.address.city", false},
+		{"**.email", "email", true},
+		{"**.email", "order.buyer.email", true},
+		{"**.email", "order.buyer.emails", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.keyPath, func(t *testing.T) {
+			r, err := ParseRule(tt.pattern)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.matches(strings.Split(tt.keyPath, ".")); got != tt.want {
+				t.Errorf("matches(%q) = %v, want %v", tt.keyPath, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRuleApply(t *testing.T) {
+	tests := []struct {
+		spec  string
+		value interface{}
+		want  interface{}
+	}{
+		{"password", "secret", Mask},
+		{"password", 123456, Mask},
+		{"password", nil, nil},
+		{"card:last:4", "6222020012345678", Mask + "5678"},
+		{"card:last:4", "678", Mask},
+		{"name:last:1", "张三丰", Mask + "丰"},
+		{"email:email", "jane@example.com", Mask + "@example.com"},
+		{"email:email", "jane", Mask},
+		{"id:hash", "42", "sha256:73475cb40a568e8d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			r, err := ParseRule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.Apply(tt.value); got != tt.want {
+				t.Errorf("Apply(%v) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+	"top-ping/pkg/database"
+)
+
+const (
+	lockName = "top-ping-migrate"
+	// lockKey pg_advisory_lock takes a bigint, crc32 of lockName
+	lockKey     = 1707807956
+	lockTimeout = time.Minute
+)
+
+// lock take the advisory lock on a dedicated connection, migrations of other instances wait for it.
+// sqlite has no advisory locks, its writes are serialized by the file lock anyway.
+func lock(ctx context.Context, db *sql.DB, driver string) (unlock func(), err error) {
+	if driver == database.SqliteDriver {
+		return func() {}, nil
+	}
+
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	switch driver {
+	case database.MysqlDriver:
+		var got sql.NullInt64
+		err = conn.QueryRowContext(ctx, "SELECT GET_LOCK(?, ?)", lockName, int(lockTimeout.Seconds())).Scan(&got)
+		if err == nil && got.Int64 != 1 {
+			err = fmt.Errorf("lock %s: timeout after %s", lockName, lockTimeout)
+		}
+		if err != nil {
+			conn.Close()
+			return nil, err
+		}
+
+		return func() {
+			_, _ = conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", lockName)
+			conn.Close()
+		}, nil
+	case database.PostgresDriver:
+		lockCtx, cancel := context.WithTimeout(ctx, lockTimeout)
+		defer cancel()
+		if _, err = conn.ExecContext(lockCtx, "SELECT pg_advisory_lock($1)", lockKey); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("lock %s: %w", lockName, err)
+		}
+
+		return func() {
+			_, _ = conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", lockKey)
+			conn.Close()
+		}, nil
+	}
+
+	conn.Close()
+	return nil, fmt.Errorf("lock %s: unsupported driver %q", lockName, driver)
+}
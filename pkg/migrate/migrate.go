@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"context"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+	"time"
+	"top-ping/pkg/logger"
+)
+
+// schemaMigration a row of the history table, one per applied version
+type schemaMigration struct {
+	Version   int64     `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"size:255;not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+func (schemaMigration) TableName() string {
+	return "schema_migrations"
+}
+
+// Status a migration and when it was applied, AppliedAt is nil when pending
+type Status struct {
+	Migration
+	AppliedAt *time.Time
+}
+
+type Migrator struct {
+	db         *gorm.DB
+	driver     string
+	migrations []Migration
+}
+
+// New a migrator of the embedded migrations of driver
+func New(db *gorm.DB, driver string) (*Migrator, error) {
+	migrations, err := load(driver)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, driver: driver, migrations: migrations}, nil
+}
+
+// Up apply all pending migrations in order, returns the applied ones
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	var applied []Migration
+	err := m.locked(ctx, func(history map[int64]schemaMigration) error {
+		for _, migration := range m.migrations {
+			if _, ok := history[migration.Version]; ok {
+				continue
+			}
+
+			err := m.apply(ctx, migration.Up, func(tx *gorm.DB) error {
+				return tx.Create(&schemaMigration{Version: migration.Version, Name: migration.Name, AppliedAt: time.Now()}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate up %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			logger.Infof(ctx, "Migrate: applied %d_%s", migration.Version, migration.Name)
+			applied = append(applied, migration)
+		}
+		return nil
+	})
+
+	return applied, err
+}
+
+// Down revert the last n applied migrations, newest first, returns the reverted ones
+func (m *Migrator) Down(ctx context.Context, n int) ([]Migration, error) {
+	var reverted []Migration
+	err := m.locked(ctx, func(history map[int64]schemaMigration) error {
+		for i := len(m.migrations) - 1; i >= 0 && len(reverted) < n; i-- {
+			migration := m.migrations[i]
+			if _, ok := history[migration.Version]; !ok {
+				continue
+			}
+
+			err := m.apply(ctx, migration.Down, func(tx *gorm.DB) error {
+				return tx.Delete(&schemaMigration{Version: migration.Version}).Error
+			})
+			if err != nil {
+				return fmt.Errorf("migrate down %d_%s: %w", migration.Version, migration.Name, err)
+			}
+
+			logger.Infof(ctx, "Migrate: reverted %d_%s", migration.Version, migration.Name)
+			reverted = append(reverted, migration)
+		}
+		return nil
+	})
+
+	return reverted, err
+}
+
+// Status every known migration with its applied time
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	history, err := m.history(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]Status, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if row, ok := history[migration.Version]; ok {
+			appliedAt := row.AppliedAt
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+
+	return statuses, nil
+}
+
+// locked run fn holding the advisory lock, with the history read after taking it
+func (m *Migrator) locked(ctx context.Context, fn func(history map[int64]schemaMigration) error) error {
+	sqlDB, err := m.db.DB()
+	if err != nil {
+		return err
+	}
+
+	unlock, err := lock(ctx, sqlDB, m.driver)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	history, err := m.history(ctx)
+	if err != nil {
+		return err
+	}
+	return fn(history)
+}
+
+func (m *Migrator) history(ctx context.Context) (map[int64]schemaMigration, error) {
+	db := m.session(ctx)
+	if err := db.AutoMigrate(&schemaMigration{}); err != nil {
+		return nil, fmt.Errorf("create %s: %w", schemaMigration{}.TableName(), err)
+	}
+
+	var rows []schemaMigration
+	if err := db.Find(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	history := make(map[int64]schemaMigration, len(rows))
+	for _, row := range rows {
+		history[row.Version] = row
+	}
+	return history, nil
+}
+
+// session on the primary, a lagging replica would show migrations as pending that already ran
+func (m *Migrator) session(ctx context.Context) *gorm.DB {
+	return m.db.WithContext(ctx).Clauses(dbresolver.Write)
+}
+
+// apply run the statements of sql and record it in the history within one transaction
+func (m *Migrator) apply(ctx context.Context, sql string, record func(tx *gorm.DB) error) error {
+	return m.session(ctx).Transaction(func(tx *gorm.DB) error {
+		for _, statement := range statements(sql) {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return record(tx)
+	})
+}
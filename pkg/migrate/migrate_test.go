@@ -0,0 +1,169 @@
+package migrate
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"top-ping/pkg/database"
+	"top-ping/pkg/logger"
+)
+
+func TestMain(m *testing.M) {
+	disabled := false
+	logger.Init("test", &logger.Config{Level: "error", Stdout: logger.OutputConfig{Enabled: &disabled}, File: logger.OutputConfig{Enabled: &disabled}})
+	os.Exit(m.Run())
+}
+
+func TestStatements(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{name: "empty", sql: "\n  \n"},
+		{name: "one", sql: "CREATE TABLE a (id INT);", want: []string{"CREATE TABLE a (id INT);"}},
+		{
+			name: "several with comments",
+			sql:  "-- users\nCREATE TABLE a (\n  id INT\n);\n\n-- index\nCREATE INDEX i ON a (id);\n",
+			want: []string{"CREATE TABLE a (\n  id INT\n);", "CREATE INDEX i ON a (id);"},
+		},
+		{
+			name: "semicolon inside a line",
+			sql:  "INSERT INTO a VALUES ('x;y');\nUPDATE a SET id = 1",
+			want: []string{"INSERT INTO a VALUES ('x;y');", "UPDATE a SET id = 1"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := statements(tt.sql); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("statements() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFileRegexp(t *testing.T) {
+	tests := []struct {
+		name    string
+		matches []string
+	}{
+		{name: "20240101_create_user.up.sql", matches: []string{"20240101", "create_user", "up"}},
+		{name: "2_x.down.sql", matches: []string{"2", "x", "down"}},
+		{name: "create_user.up.sql"},
+		{name: "1_create-user.up.sql"},
+		{name: "1_create_user.sql"},
+		{name: "README.md"},
+	}
+
+	for _, tt := range tests {
+		got := fileRegexp.FindStringSubmatch(tt.name)
+		if got != nil {
+			got = got[1:]
+		}
+		if !reflect.DeepEqual(got, tt.matches) {
+			t.Errorf("%s matches %q, want %q", tt.name, got, tt.matches)
+		}
+	}
+}
+
+func TestLoadWithoutMigrations(t *testing.T) {
+	for _, driver := range []string{database.MysqlDriver, database.PostgresDriver, database.SqliteDriver} {
+		if migrations, err := load(driver); err != nil || len(migrations) != 0 {
+			t.Errorf("load(%s) = %v, %v, want none", driver, migrations, err)
+		}
+	}
+}
+
+func TestUpDown(t *testing.T) {
+	ctx := context.Background()
+	db, err := database.NewDB(ctx, &database.DatasourceConfig{DriverName: database.SqliteDriver, Database: filepath.Join(t.TempDir(), "test.db")})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m := &Migrator{db: db, driver: database.SqliteDriver, migrations: []Migration{
+		{Version: 1, Name: "create_a", Up: "CREATE TABLE a (id INTEGER);", Down: "DROP TABLE a;"},
+		{Version: 2, Name: "create_b", Up: "CREATE TABLE b (id INTEGER);\nCREATE INDEX b_id ON b (id);", Down: "DROP TABLE b;"},
+		{Version: 3, Name: "broken", Up: "CREATE TABLE c (id INTEGER);\nNOT SQL;", Down: "DROP TABLE c;"},
+	}}
+	steps := []struct {
+		name    string
+		run     func() ([]Migration, error)
+		changed []int64
+		err     string
+		applied []int64
+		tables  []string
+	}{
+		{
+			name:    "up stops at the broken one",
+			run:     func() ([]Migration, error) { return m.Up(ctx) },
+			changed: []int64{1, 2},
+			err:     "migrate up 3_broken",
+			applied: []int64{1, 2},
+			// the failed migration is rolled back as a whole
+			tables: []string{"a", "b"},
+		},
+		{
+			name:    "down one",
+			run:     func() ([]Migration, error) { return m.Down(ctx, 1) },
+			changed: []int64{2},
+			applied: []int64{1},
+			tables:  []string{"a"},
+		},
+		{
+			name:    "down more than applied",
+			run:     func() ([]Migration, error) { return m.Down(ctx, 5) },
+			changed: []int64{1},
+		},
+		{
+			name: "down nothing",
+			run:  func() ([]Migration, error) { return m.Down(ctx, 1) },
+		},
+	}
+
+	for _, step := range steps {
+		changed, err := step.run()
+		if step.err == "" && err != nil || step.err != "" && (err == nil || !strings.Contains(err.Error(), step.err)) {
+			t.Fatalf("%s: error = %v, want %q", step.name, err, step.err)
+		}
+		if got := versions(changed); !reflect.DeepEqual(got, step.changed) {
+			t.Errorf("%s: changed %v, want %v", step.name, got, step.changed)
+		}
+
+		statuses, err := m.Status(ctx)
+		if err != nil {
+			t.Fatalf("%s: Status() error = %v", step.name, err)
+		}
+		var applied []int64
+		for _, status := range statuses {
+			if status.AppliedAt != nil {
+				applied = append(applied, status.Version)
+			}
+		}
+		if !reflect.DeepEqual(applied, step.applied) {
+			t.Errorf("%s: applied %v, want %v", step.name, applied, step.applied)
+		}
+
+		for _, table := range []string{"a", "b", "c"} {
+			want := false
+			for _, name := range step.tables {
+				want = want || name == table
+			}
+			if got := db.Migrator().HasTable(table); got != want {
+				t.Errorf("%s: table %s exists = %v, want %v", step.name, table, got, want)
+			}
+		}
+	}
+}
+
+func versions(migrations []Migration) []int64 {
+	var result []int64
+	for _, migration := range migrations {
+		result = append(result, migration.Version)
+	}
+	return result
+}
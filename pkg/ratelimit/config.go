@@ -0,0 +1,32 @@
+package ratelimit
+
+import "fmt"
+
+type Config struct {
+	Enabled bool                   `mapstructure:"enabled"`
+	Groups  map[string]GroupConfig `mapstructure:"groups"`
+	// MaxKeys buckets kept in memory, the least recently used are evicted beyond it, 100000 when 0
+	MaxKeys int `mapstructure:"maxKeys"`
+}
+
+// GroupConfig token bucket settings of one route group, Rate is tokens per second
+type GroupConfig struct {
+	Rate  float64 `mapstructure:"rate"`
+	Burst int     `mapstructure:"burst"`
+}
+
+func (c *Config) Validate() error {
+	if c.MaxKeys < 0 {
+		return fmt.Errorf("rateLimit.maxKeys: must not be negative")
+	}
+	for name, group := range c.Groups {
+		if group.Rate <= 0 {
+			return fmt.Errorf("rateLimit.groups.%s.rate: must be greater than 0", name)
+		}
+		if group.Burst <= 0 {
+			return fmt.Errorf("rateLimit.groups.%s.burst: must be greater than 0", name)
+		}
+	}
+
+	return nil
+}
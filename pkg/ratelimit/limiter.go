@@ -0,0 +1,182 @@
+package ratelimit
+
+import (
+	"container/list"
+	"math"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultGroup = "default"
+
+	defaultMaxKeys = 100000
+	sweepInterval  = time.Minute
+)
+
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type Limiter struct {
+	mu      sync.Mutex
+	config  *Config
+	buckets map[string]*list.Element
+	// lru buckets by last use, the most recent at the front
+	lru       *list.List
+	lastSweep time.Time
+}
+
+type bucket struct {
+	key      string
+	tokens   float64
+	last     time.Time
+	rate     float64
+	burst    int
+	lastSeen time.Time
+}
+
+func NewLimiter(config *Config) *Limiter {
+	return &Limiter{
+		config:    config,
+		buckets:   map[string]*list.Element{},
+		lru:       list.New(),
+		lastSweep: time.Now(),
+	}
+}
+
+// Update replace the limits, existing buckets are dropped
+func (l *Limiter) Update(config *Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.config = config
+	l.buckets = map[string]*list.Element{}
+	l.lru.Init()
+}
+
+// Take consume one token of the key in the group, ok is false when the group is not limited
+func (l *Limiter) Take(group, key string) (result Result, ok bool) {
+	return l.take(group, key, time.Now())
+}
+
+func (l *Limiter) take(group, key string, now time.Time) (Result, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	groupConfig, ok := l.groupConfig(group)
+	if !ok {
+		return Result{}, false
+	}
+
+	l.sweep(now)
+
+	bucketKey := group + "|" + key
+	var b *bucket
+	if elem, exist := l.buckets[bucketKey]; exist {
+		l.lru.MoveToFront(elem)
+		b = elem.Value.(*bucket)
+	} else {
+		l.evict()
+		b = &bucket{
+			key:    bucketKey,
+			tokens: float64(groupConfig.Burst),
+			last:   now,
+			rate:   groupConfig.Rate,
+			burst:  groupConfig.Burst,
+		}
+		l.buckets[bucketKey] = l.lru.PushFront(b)
+	}
+
+	return b.take(now), true
+}
+
+// Len number of buckets in memory
+func (l *Limiter) Len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.buckets)
+}
+
+func (l *Limiter) groupConfig(group string) (GroupConfig, bool) {
+	if l.config == nil || !l.config.Enabled {
+		return GroupConfig{}, false
+	}
+
+	groupConfig, exist := l.config.Groups[group]
+	if !exist {
+		groupConfig, exist = l.config.Groups[DefaultGroup]
+	}
+	if !exist || groupConfig.Rate <= 0 || groupConfig.Burst <= 0 {
+		return GroupConfig{}, false
+	}
+
+	return groupConfig, true
+}
+
+func (l *Limiter) maxKeys() int {
+	if l.config.MaxKeys <= 0 {
+		return defaultMaxKeys
+	}
+	return l.config.MaxKeys
+}
+
+// evict make room for a new bucket by dropping the least recently used ones, in O(1) even under a flood of keys
+func (l *Limiter) evict() {
+	for len(l.buckets) >= l.maxKeys() {
+		l.remove(l.lru.Back())
+	}
+}
+
+// sweep drop the buckets which are full again, they are the same as new ones
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < sweepInterval {
+		return
+	}
+	l.lastSweep = now
+
+	// buckets of another group may fill faster, so look at all of them, not only the lru tail
+	for elem := l.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if b := elem.Value.(*bucket); now.Sub(b.lastSeen) > b.fillTime() {
+			l.remove(elem)
+		}
+		elem = prev
+	}
+}
+
+func (l *Limiter) remove(elem *list.Element) {
+	delete(l.buckets, elem.Value.(*bucket).key)
+	l.lru.Remove(elem)
+}
+
+func (b *bucket) take(now time.Time) Result {
+	b.tokens = math.Min(float64(b.burst), b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.lastSeen = now
+
+	result := Result{Limit: b.burst}
+	if b.tokens >= 1 {
+		b.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = b.duration(1 - b.tokens)
+	}
+	result.Remaining = int(b.tokens)
+	result.Reset = b.duration(float64(b.burst) - b.tokens)
+
+	return result
+}
+
+func (b *bucket) fillTime() time.Duration {
+	return b.duration(float64(b.burst))
+}
+
+func (b *bucket) duration(tokens float64) time.Duration {
+	return time.Duration(tokens / b.rate * float64(time.Second))
+}
@@ -0,0 +1,179 @@
+package ratelimit
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTake(t *testing.T) {
+	// 2 tokens per second, 3 at most
+	config := &Config{Enabled: true, Groups: map[string]GroupConfig{DefaultGroup: {Rate: 2, Burst: 3}}}
+	start := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	steps := []struct {
+		after      time.Duration
+		allowed    bool
+		remaining  int
+		retryAfter time.Duration
+		reset      time.Duration
+	}{
+		{after: 0, allowed: true, remaining: 2, reset: 500 * time.Millisecond},
+		{after: 0, allowed: true, remaining: 1, reset: time.Second},
+		{after: 0, allowed: true, remaining: 0, reset: 1500 * time.Millisecond},
+		{after: 0, allowed: false, remaining: 0, retryAfter: 500 * time.Millisecond, reset: 1500 * time.Millisecond},
+		{after: 250 * time.Millisecond, allowed: false, remaining: 0, retryAfter: 250 * time.Millisecond, reset: 1250 * time.Millisecond},
+		{after: 500 * time.Millisecond, allowed: true, remaining: 0, reset: 1250 * time.Millisecond},
+		// never more than the burst after a long pause
+		{after: time.Hour, allowed: true, remaining: 2, reset: 500 * time.Millisecond},
+	}
+
+	l := NewLimiter(config)
+	now := start
+	for i, step := range steps {
+		now = now.Add(step.after)
+		result, ok := l.take("api", "1.2.3.4", now)
+		if !ok {
+			t.Fatalf("step %d: group not limited", i)
+		}
+		want := Result{Allowed: step.allowed, Limit: 3, Remaining: step.remaining, Reset: step.reset, RetryAfter: step.retryAfter}
+		if result != want {
+			t.Errorf("step %d: take() = %+v, want %+v", i, result, want)
+		}
+	}
+}
+
+func TestGroups(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  *Config
+		group   string
+		limited bool
+		limit   int
+	}{
+		{name: "disabled", config: &Config{Groups: map[string]GroupConfig{DefaultGroup: {Rate: 1, Burst: 1}}}, group: "api"},
+		{name: "nil config", group: "api"},
+		{name: "no group", config: &Config{Enabled: true}, group: "api"},
+		{name: "own group", config: &Config{Enabled: true, Groups: map[string]GroupConfig{"api": {Rate: 1, Burst: 5}, DefaultGroup: {Rate: 1, Burst: 9}}}, group: "api", limited: true, limit: 5},
+		{name: "default group", config: &Config{Enabled: true, Groups: map[string]GroupConfig{DefaultGroup: {Rate: 1, Burst: 9}}}, group: "api", limited: true, limit: 9},
+		{name: "invalid group", config: &Config{Enabled: true, Groups: map[string]GroupConfig{"api": {Rate: 0, Burst: 5}}}, group: "api"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, ok := NewLimiter(tt.config).Take(tt.group, "key")
+			if ok != tt.limited || result.Limit != tt.limit {
+				t.Errorf("Take() = %+v, %v, want limit %d, %v", result, ok, tt.limit, tt.limited)
+			}
+		})
+	}
+}
+
+func TestKeysAreSeparate(t *testing.T) {
+	l := NewLimiter(&Config{Enabled: true, Groups: map[string]GroupConfig{DefaultGroup: {Rate: 1, Burst: 1}}})
+	now := time.Now()
+
+	for _, key := range []string{"a", "b"} {
+		if result, _ := l.take("api", key, now); !result.Allowed {
+			t.Errorf("first take of %s denied", key)
+		}
+	}
+	if result, _ := l.take("api", "a", now); result.Allowed {
+		t.Error("second take of a allowed")
+	}
+	if result, _ := l.take("admin", "a", now); !result.Allowed {
+		t.Error("take of a in another group denied")
+	}
+}
+
+func TestEvictLeastRecentlyUsed(t *testing.T) {
+	l := NewLimiter(&Config{Enabled: true, MaxKeys: 3, Groups: map[string]GroupConfig{DefaultGroup: {Rate: 0.001, Burst: 1}}})
+	now := time.Now()
+
+	for _, key := range []string{"a", "b", "c"} {
+		l.take("api", key, now)
+	}
+	// a is used again, b is the least recently used now
+	l.take("api", "a", now)
+	l.take("api", "d", now)
+
+	if l.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", l.Len())
+	}
+	for key, evicted := range map[string]bool{"a": false, "b": true, "c": false, "d": false} {
+		if _, exist := l.buckets["api|"+key]; exist == evicted {
+			t.Errorf("bucket %s kept = %v, want %v", key, exist, !evicted)
+		}
+	}
+
+	// a flood of new keys never grows past the cap
+	for i := 0; i < 1000; i++ {
+		l.take("api", fmt.Sprint(i), now)
+	}
+	if l.Len() != 3 {
+		t.Errorf("Len() = %d after a flood, want 3", l.Len())
+	}
+}
+
+func TestSweep(t *testing.T) {
+	// fast refills in 1s, slow in 100s
+	l := NewLimiter(&Config{Enabled: true, Groups: map[string]GroupConfig{
+		"fast": {Rate: 10, Burst: 10},
+		"slow": {Rate: 1, Burst: 100},
+	}})
+	now := l.lastSweep
+
+	l.take("slow", "a", now)
+	l.take("fast", "a", now)
+	// sweeps, fast|a is full again, slow|a is not
+	l.take("fast", "b", now.Add(sweepInterval))
+	// no sweep until the next interval, fast|b stays though it is full again
+	l.take("fast", "c", now.Add(sweepInterval+2*time.Second))
+	for key, kept := range map[string]bool{"slow|a": true, "fast|a": false, "fast|b": true, "fast|c": true} {
+		if _, exist := l.buckets[key]; exist != kept {
+			t.Errorf("bucket %s kept = %v, want %v", key, exist, kept)
+		}
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	l := NewLimiter(&Config{Enabled: true, Groups: map[string]GroupConfig{DefaultGroup: {Rate: 1, Burst: 1}}})
+	l.Take("api", "a")
+
+	l.Update(&Config{Enabled: true, Groups: map[string]GroupConfig{DefaultGroup: {Rate: 1, Burst: 7}}})
+	if l.Len() != 0 {
+		t.Errorf("Len() = %d after Update, want 0", l.Len())
+	}
+	if result, _ := l.Take("api", "a"); !result.Allowed || result.Limit != 7 {
+		t.Errorf("Take() = %+v after Update, want the new limit", result)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		config Config
+		err    string
+	}{
+		{name: "empty", config: Config{}},
+		{name: "valid", config: Config{Enabled: true, MaxKeys: 10, Groups: map[string]GroupConfig{"api": {Rate: 0.5, Burst: 1}}}},
+		{name: "negative max keys", config: Config{MaxKeys: -1}, err: "rateLimit.maxKeys"},
+		{name: "zero rate", config: Config{Groups: map[string]GroupConfig{"api": {Rate: 0, Burst: 1}}}, err: "rateLimit.groups.api.rate"},
+		{name: "zero burst", config: Config{Groups: map[string]GroupConfig{"api": {Rate: 1}}}, err: "rateLimit.groups.api.burst"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("Validate() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"reflect"
+	"time"
+)
+
+// cursor values of the order columns of the last row, opaque to clients
+type cursor []interface{}
+
+func (r *Repository[T]) nextCursor(ctx context.Context, s *schema.Schema, orders []order, last *T) (string, error) {
+	var err error
+	c := make(cursor, 0, len(orders))
+	for _, o := range orders {
+		field := s.LookUpField(o.column)
+		if field == nil {
+			return "", errors.New("cursor: no field of column " + o.column)
+		}
+
+		value, _ := field.ValueOf(ctx, reflect.ValueOf(last).Elem())
+		if rv := reflect.ValueOf(value); rv.Kind() == reflect.Ptr && rv.IsNil() {
+			value = nil
+		} else if valuer, ok := value.(driver.Valuer); ok {
+			// e.g. sql.NullString, null or its value rather than the struct
+			if value, err = valuer.Value(); err != nil {
+				return "", err
+			}
+		}
+		c = append(c, value)
+	}
+
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// decodeCursor the cursor of orders, its values converted to the field types of the columns.
+// A value of another type is an error, the database would reject it or compare it as a string.
+func decodeCursor(s *schema.Schema, orders []order, encoded string) (cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	var c cursor
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&c); err != nil {
+		return nil, err
+	}
+	if len(c) != len(orders) {
+		// a cursor of another sort
+		return nil, fmt.Errorf("cursor: %d values for %d orders", len(c), len(orders))
+	}
+
+	for i, o := range orders {
+		field := s.LookUpField(o.column)
+		if field == nil {
+			return nil, errors.New("cursor: no field of column " + o.column)
+		}
+		if c[i] == nil && !o.nullable {
+			return nil, fmt.Errorf("cursor: %s: null of a column that is not nullable", o.column)
+		}
+		if c[i], err = cursorValue(field.DataType, c[i]); err != nil {
+			return nil, fmt.Errorf("cursor: %s: %w", o.column, err)
+		}
+	}
+	return c, nil
+}
+
+// cursorValue convert a json value to the go type of dataType, null stays null
+func cursorValue(dataType schema.DataType, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	switch dataType {
+	case schema.Int, schema.Uint:
+		if n, ok := v.(json.Number); ok {
+			if integer, err := n.Int64(); err == nil && (dataType == schema.Int || integer >= 0) {
+				return integer, nil
+			}
+		}
+	case schema.Float:
+		if n, ok := v.(json.Number); ok {
+			if float, err := n.Float64(); err == nil {
+				return float, nil
+			}
+		}
+	case schema.String:
+		if str, ok := v.(string); ok {
+			return str, nil
+		}
+	case schema.Time:
+		if str, ok := v.(string); ok {
+			if t, err := time.Parse(time.RFC3339Nano, str); err == nil {
+				return t, nil
+			}
+		}
+	case schema.Bool:
+		if b, ok := v.(bool); ok {
+			return b, nil
+		}
+	default:
+		// custom types, e.g. decimals, bound as they are
+		if n, ok := v.(json.Number); ok {
+			return n.String(), nil
+		}
+		return v, nil
+	}
+
+	return nil, fmt.Errorf("%v is not a %s", v, dataType)
+}
+
+var valuerType = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+
+// nullable a field of a pointer or a driver.Valuer like sql.NullString, other fields can't tell null from zero
+// and their columns are taken for NOT NULL
+func nullable(field *schema.Field) bool {
+	if field == nil || field.PrimaryKey || field.NotNull {
+		return false
+	}
+	return field.FieldType.Kind() == reflect.Ptr || field.FieldType.Implements(valuerType)
+}
+
+// orderBy e.g. a IS NULL, a DESC, id DESC, the nulls of nullable columns last in either direction
+// as databases disagree on where they put them
+func orderBy(orders []order) clause.OrderBy {
+	exprs := make([]clause.Expression, 0, len(orders)*2)
+	for _, o := range orders {
+		column := clause.Column{Name: o.column}
+		if o.nullable {
+			exprs = append(exprs, clause.Expr{SQL: "? IS NULL", Vars: []interface{}{column}})
+		}
+		if o.desc {
+			exprs = append(exprs, clause.Expr{SQL: "? DESC", Vars: []interface{}{column}})
+		} else {
+			exprs = append(exprs, clause.Expr{SQL: "?", Vars: []interface{}{column}})
+		}
+	}
+	return clause.OrderBy{Expression: clause.CommaExpression{Exprs: exprs}}
+}
+
+// keyset rows after the cursor, e.g. (a > ?) OR (a = ? AND id > ?) for orders a, id.
+// Nulls sort last: after a value come the greater values and the nulls, after a null only the nulls, e.g.
+// (a > ? OR a IS NULL) OR (a = ? AND id > ?), and (a IS NULL AND id > ?) when the cursor has a null a.
+func keyset(orders []order, c cursor) clause.Expression {
+	var or []clause.Expression
+	for i, o := range orders {
+		if c[i] == nil {
+			// nothing sorts after a null of this column but the rows after it by the next ones
+			continue
+		}
+
+		and := make([]clause.Expression, 0, i+1)
+		for j := 0; j < i; j++ {
+			// Eq of a nil value builds IS NULL
+			and = append(and, clause.Eq{Column: clause.Column{Name: orders[j].column}, Value: c[j]})
+		}
+
+		column := clause.Column{Name: o.column}
+		var after clause.Expression = clause.Gt{Column: column, Value: c[i]}
+		if o.desc {
+			after = clause.Lt{Column: column, Value: c[i]}
+		}
+		if o.nullable {
+			after = clause.Or(after, clause.Eq{Column: column, Value: nil})
+		}
+		or = append(or, clause.And(append(and, after)...))
+	}
+
+	return clause.Or(or...)
+}
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"top-ping/pkg/baseerr"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+// reserved query params, all others are filters
+var reserved = map[string]bool{"limit": true, "offset": true, "cursor": true, "sort": true}
+
+type order struct {
+	column string
+	desc   bool
+	// nullable nulls sort last in either direction
+	nullable bool
+}
+
+type filter struct {
+	column string
+	values []string
+}
+
+// query a list request parsed from the query string
+type query struct {
+	limit   int
+	offset  int
+	cursor  string
+	orders  []order
+	filters []filter
+}
+
+// parseQuery limit, offset, cursor, sort=-created_at,name and field=a,b filters, unknown fields are rejected.
+// pk is the primary key column, a cursor sorts by one field and optionally the primary key after it.
+func (r *Repository[T]) parseQuery(values url.Values, pk string) (*query, error) {
+	q := &query{limit: defaultLimit}
+	var fieldErrors []interface{}
+
+	if v := values.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit <= 0 || limit > maxLimit {
+			fieldErrors = append(fieldErrors, baseerr.FieldError{Field: "limit", Rule: "range", Message: "limit must be between 1 and " + strconv.Itoa(maxLimit)})
+		}
+		q.limit = limit
+	}
+	if v := values.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			fieldErrors = append(fieldErrors, baseerr.FieldError{Field: "offset", Rule: "min", Message: "offset must not be negative"})
+		}
+		q.offset = offset
+	}
+	q.cursor = values.Get("cursor")
+	if q.cursor != "" && q.offset > 0 {
+		fieldErrors = append(fieldErrors, baseerr.FieldError{Field: "cursor", Rule: "excluded_with", Message: "cursor and offset can't be used together"})
+	}
+
+	sorts := values.Get("sort")
+	if sorts == "" {
+		sorts = r.options.DefaultSort
+	}
+	for _, field := range strings.Split(sorts, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+
+		desc := strings.HasPrefix(field, "-")
+		name := strings.TrimPrefix(field, "-")
+		column, ok := r.options.Sorts[name]
+		if !ok {
+			fieldErrors = append(fieldErrors, baseerr.FieldError{Field: "sort", Rule: "oneof", Message: name + " is not sortable"})
+			continue
+		}
+		q.orders = append(q.orders, order{column: column, desc: desc})
+	}
+	if q.cursor != "" && len(q.orders) > 1 && !(len(q.orders) == 2 && q.orders[1].column == pk) {
+		fieldErrors = append(fieldErrors, baseerr.FieldError{Field: "sort", Rule: "max", Message: "cursor pagination sorts by one field only"})
+	}
+
+	for name, vs := range values {
+		if reserved[name] {
+			continue
+		}
+
+		column, ok := r.options.Filters[name]
+		if !ok {
+			fieldErrors = append(fieldErrors, baseerr.FieldError{Field: name, Rule: "oneof", Message: name + " is not filterable"})
+			continue
+		}
+
+		var filterValues []string
+		for _, v := range vs {
+			filterValues = append(filterValues, strings.Split(v, ",")...)
+		}
+		q.filters = append(q.filters, filter{column: column, values: filterValues})
+	}
+
+	// map order is random, keep the sql stable
+	sort.Slice(q.filters, func(i, j int) bool {
+		return q.filters[i].column < q.filters[j].column
+	})
+
+	if len(fieldErrors) > 0 {
+		return nil, baseerr.ErrInvalidParam.WithDetails(fieldErrors...)
+	}
+	return q, nil
+}
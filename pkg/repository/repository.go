@@ -0,0 +1,204 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+	"net/url"
+	"reflect"
+	"sync"
+	"top-ping/pkg/baseerr"
+	"top-ping/pkg/database"
+)
+
+// Options whitelists of the query string, keys are query param names and values are columns
+type Options struct {
+	Filters map[string]string
+	// Sorts nulls sort last for pointer and sql.Null* fields, columns of other fields are taken for NOT NULL
+	Sorts map[string]string
+	// DefaultSort e.g. "-created_at", the primary key when empty
+	DefaultSort string
+}
+
+// Page the list shape put in rest.Response.Data
+type Page[T any] struct {
+	Items []T   `json:"items"`
+	Total int64 `json:"total"`
+	// NextCursor empty on the last page, or when the page was fetched by offset
+	NextCursor string `json:"nextCursor,omitempty"`
+}
+
+// Repository crud and list queries of model T, errors are baseerr errors ready for rest.R.Error
+type Repository[T any] struct {
+	options Options
+
+	schemaOnce sync.Once
+	schema     *schema.Schema
+	schemaErr  error
+}
+
+func New[T any](options Options) *Repository[T] {
+	return &Repository[T]{options: options}
+}
+
+// WithContext a session of model T, queries stop when ctx is cancelled
+func (r *Repository[T]) WithContext(ctx context.Context) *gorm.DB {
+	return database.WithContext(ctx).Model(new(T))
+}
+
+func (r *Repository[T]) Get(ctx context.Context, id interface{}) (*T, error) {
+	s, err := r.parseSchema()
+	if err != nil {
+		return nil, err
+	}
+
+	var item T
+	err = r.WithContext(ctx).Where(clause.Eq{Column: clause.Column{Name: s.PrioritizedPrimaryField.DBName}, Value: id}).Take(&item).Error
+	if err != nil {
+		return nil, dbError(err)
+	}
+	return &item, nil
+}
+
+func (r *Repository[T]) Create(ctx context.Context, item *T) error {
+	return dbError(r.WithContext(ctx).Create(item).Error)
+}
+
+// Update save all fields of the existing row of item's primary key, ErrNotFound when there is none.
+// Unlike gorm's Save it never inserts.
+func (r *Repository[T]) Update(ctx context.Context, item *T) error {
+	s, err := r.parseSchema()
+	if err != nil {
+		return err
+	}
+	if _, zero := s.PrioritizedPrimaryField.ValueOf(ctx, reflect.ValueOf(item).Elem()); zero {
+		return baseerr.ErrNotFound
+	}
+
+	result := database.WithContext(ctx).Model(item).Select("*").Updates(item)
+	if result.Error != nil {
+		return dbError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return baseerr.ErrNotFound
+	}
+	return nil
+}
+
+func (r *Repository[T]) Delete(ctx context.Context, id interface{}) error {
+	s, err := r.parseSchema()
+	if err != nil {
+		return err
+	}
+
+	result := r.WithContext(ctx).Where(clause.Eq{Column: clause.Column{Name: s.PrioritizedPrimaryField.DBName}, Value: id}).Delete(new(T))
+	if result.Error != nil {
+		return dbError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return baseerr.ErrNotFound
+	}
+	return nil
+}
+
+// List a page filtered and sorted by the query string, by cursor when it has one, by offset otherwise
+func (r *Repository[T]) List(ctx context.Context, values url.Values) (*Page[T], error) {
+	s, err := r.parseSchema()
+	if err != nil {
+		return nil, err
+	}
+	// the primary key breaks ties so pages never overlap
+	pk := s.PrioritizedPrimaryField.DBName
+	q, err := r.parseQuery(values, pk)
+	if err != nil {
+		return nil, err
+	}
+
+	db := r.WithContext(ctx)
+	for _, f := range q.filters {
+		db = db.Where(clause.IN{Column: clause.Column{Name: f.column}, Values: toInterfaces(f.values)})
+	}
+
+	page := &Page[T]{Items: []T{}}
+	if err := db.Session(&gorm.Session{}).Count(&page.Total).Error; err != nil {
+		return nil, dbError(err)
+	}
+
+	orders := append([]order{}, q.orders...)
+	desc := len(orders) > 0 && orders[0].desc
+	if len(orders) == 0 || orders[len(orders)-1].column != pk {
+		orders = append(orders, order{column: pk, desc: desc})
+	}
+	for i := range orders {
+		orders[i].nullable = nullable(s.LookUpField(orders[i].column))
+	}
+	db = db.Order(orderBy(orders))
+
+	if q.cursor != "" {
+		c, err := decodeCursor(s, orders, q.cursor)
+		if err != nil {
+			return nil, baseerr.ErrInvalidParam.WithDetails(baseerr.FieldError{Field: "cursor", Rule: "cursor", Message: "invalid cursor"})
+		}
+		db = db.Where(keyset(orders, c))
+	} else {
+		db = db.Offset(q.offset)
+	}
+
+	// one more row tells if there is a next page
+	if err := db.Limit(q.limit + 1).Find(&page.Items).Error; err != nil {
+		return nil, dbError(err)
+	}
+	if len(page.Items) <= q.limit {
+		return page, nil
+	}
+
+	page.Items = page.Items[:q.limit]
+	// parseQuery accepts the same sorts back as cursor
+	if q.offset == 0 && len(orders) <= 2 {
+		page.NextCursor, err = r.nextCursor(ctx, s, orders, &page.Items[q.limit-1])
+		if err != nil {
+			return nil, baseerr.ErrInternalServer.Wrap(err)
+		}
+	}
+	return page, nil
+}
+
+func (r *Repository[T]) parseSchema() (*schema.Schema, error) {
+	r.schemaOnce.Do(func() {
+		r.schema, r.schemaErr = schema.Parse(new(T), &sync.Map{}, schema.NamingStrategy{SingularTable: true})
+		if r.schemaErr == nil && r.schema.PrioritizedPrimaryField == nil {
+			r.schemaErr = fmt.Errorf("repository %s: a primary key is required", r.schema.Name)
+		}
+	})
+
+	if r.schemaErr != nil {
+		return nil, baseerr.ErrInternalServer.Wrap(r.schemaErr)
+	}
+	return r.schema, nil
+}
+
+func dbError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return baseerr.ErrNotFound.Wrap(err)
+	}
+	// e.g. ErrServiceUnavailable while the database is not connected
+	var baseErr *baseerr.Error
+	if errors.As(err, &baseErr) {
+		return err
+	}
+	return baseerr.ErrDatabase.Wrap(err)
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
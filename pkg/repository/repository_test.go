@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+	"top-ping/pkg/baseerr"
+	"top-ping/pkg/database"
+	"top-ping/pkg/logger"
+)
+
+type item struct {
+	ID        int64 `gorm:"primaryKey"`
+	Name      string
+	Score     float64
+	Kind      string
+	Level     *int
+	CreatedAt time.Time
+}
+
+var (
+	items = New[item](Options{
+		Filters:     map[string]string{"kind": "kind"},
+		Sorts:       map[string]string{"id": "id", "name": "name", "score": "score", "level": "level", "createdAt": "created_at"},
+		DefaultSort: "-createdAt",
+	})
+	created = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+func TestMain(m *testing.M) {
+	disabled := false
+	logger.Init("test", &logger.Config{Level: "error", Stdout: logger.OutputConfig{Enabled: &disabled}, File: logger.OutputConfig{Enabled: &disabled}})
+
+	dir, err := os.MkdirTemp("", "repository")
+	if err != nil {
+		panic(err)
+	}
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	err = database.Init(ctx, &database.DatasourceConfig{DriverName: database.SqliteDriver, Database: filepath.Join(dir, "test.db"), TimeZone: "UTC"})
+	if err != nil {
+		panic(err)
+	}
+	if err := database.Get().AutoMigrate(&item{}); err != nil {
+		panic(err)
+	}
+
+	// names and scores repeat so the primary key has to break the ties, levels of 3 and 6 are null
+	for i := 1; i <= 7; i++ {
+		row := &item{ID: int64(i), Name: fmt.Sprintf("n%d", (i+1)/2), Score: float64(i%3) / 2, Kind: []string{"a", "b"}[i%2], CreatedAt: created.Add(time.Duration(i%4) * time.Hour)}
+		if level := i % 3; level != 0 {
+			row.Level = &level
+		}
+		if err := items.Create(ctx, row); err != nil {
+			panic(err)
+		}
+	}
+
+	code := m.Run()
+	database.Get().Exec("DROP TABLE item")
+	os.Exit(code)
+}
+
+func TestParseQuery(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    *query
+		invalid []string
+	}{
+		{
+			name:  "defaults",
+			query: "",
+			want:  &query{limit: 20, orders: []order{{column: "created_at", desc: true}}},
+		},
+		{
+			name:  "everything",
+			query: "limit=5&offset=10&sort=name,-score&kind=a,b&kind=c",
+			want: &query{limit: 5, offset: 10, orders: []order{{column: "name"}, {column: "score", desc: true}},
+				filters: []filter{{column: "kind", values: []string{"a", "b", "c"}}}},
+		},
+		{
+			name:  "cursor by one field",
+			query: "cursor=x&sort=-score",
+			want:  &query{limit: 20, cursor: "x", orders: []order{{column: "score", desc: true}}},
+		},
+		{
+			name:  "cursor by one field and the primary key",
+			query: "cursor=x&sort=name,-id",
+			want:  &query{limit: 20, cursor: "x", orders: []order{{column: "name"}, {column: "id", desc: true}}},
+		},
+		{name: "limit too big", query: "limit=101", invalid: []string{"limit"}},
+		{name: "limit not a number", query: "limit=x", invalid: []string{"limit"}},
+		{name: "negative offset", query: "offset=-1", invalid: []string{"offset"}},
+		{name: "cursor and offset", query: "cursor=x&offset=1", invalid: []string{"cursor"}},
+		{name: "cursor by two fields", query: "cursor=x&sort=name,score", invalid: []string{"sort"}},
+		{name: "not sortable", query: "sort=kind", invalid: []string{"sort"}},
+		{name: "not filterable", query: "name=x&limit=0", invalid: []string{"limit", "name"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, _ := url.ParseQuery(tt.query)
+			q, err := items.parseQuery(values, "id")
+			if tt.invalid == nil {
+				if err != nil {
+					t.Fatalf("parseQuery() error = %v", err)
+				}
+				if !reflect.DeepEqual(q, tt.want) {
+					t.Errorf("parseQuery() = %+v, want %+v", q, tt.want)
+				}
+				return
+			}
+
+			var e *baseerr.Error
+			if !errors.As(err, &e) || e.Code() != baseerr.ErrInvalidParam.Code() {
+				t.Fatalf("parseQuery() error = %v, want ErrInvalidParam", err)
+			}
+			var fields []string
+			for _, detail := range e.Details() {
+				fields = append(fields, detail.(baseerr.FieldError).Field)
+			}
+			if len(fields) > 1 && fields[0] > fields[1] {
+				fields[0], fields[1] = fields[1], fields[0]
+			}
+			if !reflect.DeepEqual(fields, tt.invalid) {
+				t.Errorf("parseQuery() invalid fields = %v, want %v", fields, tt.invalid)
+			}
+		})
+	}
+}
+
+func TestListPages(t *testing.T) {
+	tests := []struct {
+		name string
+		sort string
+		want []int64
+	}{
+		{name: "primary key", sort: "id", want: []int64{1, 2, 3, 4, 5, 6, 7}},
+		{name: "desc primary key", sort: "-id", want: []int64{7, 6, 5, 4, 3, 2, 1}},
+		{name: "string with ties", sort: "-name", want: []int64{7, 6, 5, 4, 3, 2, 1}},
+		{name: "string and primary key", sort: "name,-id", want: []int64{2, 1, 4, 3, 6, 5, 7}},
+		{name: "float with ties", sort: "score", want: []int64{3, 6, 1, 4, 7, 2, 5}},
+		{name: "time with ties", sort: "-createdAt", want: []int64{7, 3, 6, 2, 5, 1, 4}},
+		{name: "nulls last", sort: "level", want: []int64{1, 4, 7, 2, 5, 3, 6}},
+		{name: "desc nulls last", sort: "-level", want: []int64{5, 2, 7, 4, 1, 6, 3}},
+		{name: "nulls last and primary key", sort: "level,-id", want: []int64{7, 4, 1, 5, 2, 6, 3}},
+	}
+
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []int64
+			values := url.Values{"sort": {tt.sort}, "limit": {"3"}}
+			for pages := 0; ; pages++ {
+				if pages > len(tt.want) {
+					t.Fatal("List() never returned the last page")
+				}
+
+				page, err := items.List(ctx, values)
+				if err != nil {
+					t.Fatalf("List() error = %v", err)
+				}
+				if page.Total != 7 {
+					t.Errorf("List() total = %d, want 7", page.Total)
+				}
+				for _, it := range page.Items {
+					got = append(got, it.ID)
+				}
+				if page.NextCursor == "" {
+					break
+				}
+				values.Set("cursor", page.NextCursor)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("List() ids = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListOffsetAndFilters(t *testing.T) {
+	page, err := items.List(context.Background(), url.Values{"kind": {"a"}, "sort": {"id"}, "offset": {"1"}, "limit": {"2"}})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+
+	var ids []int64
+	for _, it := range page.Items {
+		ids = append(ids, it.ID)
+	}
+	// kind a is the even ids, offset pages have no cursor
+	if !reflect.DeepEqual(ids, []int64{4, 6}) || page.Total != 3 || page.NextCursor != "" {
+		t.Errorf("List() = %v, total %d, cursor %q, want [4 6], total 3, no cursor", ids, page.Total, page.NextCursor)
+	}
+}
+
+func TestListInvalidCursor(t *testing.T) {
+	encode := func(json string) string {
+		return base64.RawURLEncoding.EncodeToString([]byte(json))
+	}
+	tests := []struct {
+		name   string
+		sort   string
+		cursor string
+	}{
+		{name: "not base64", sort: "id", cursor: "!!"},
+		{name: "not json", sort: "id", cursor: encode("id=1")},
+		{name: "of another sort", sort: "name", cursor: encode(`[1]`)},
+		{name: "string for an int", sort: "id", cursor: encode(`["1"]`)},
+		{name: "fraction for an int", sort: "id", cursor: encode(`[1.5]`)},
+		{name: "number for a string", sort: "name", cursor: encode(`[1, 1]`)},
+		{name: "number for a float", sort: "score", cursor: encode(`["0.5", 1]`)},
+		{name: "not a time", sort: "createdAt", cursor: encode(`["yesterday", 1]`)},
+		{name: "null primary key", sort: "level", cursor: encode(`[1, null]`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := items.List(context.Background(), url.Values{"sort": {tt.sort}, "cursor": {tt.cursor}})
+			if !errors.Is(err, baseerr.ErrInvalidParam) {
+				t.Errorf("List() error = %v, want ErrInvalidParam", err)
+			}
+		})
+	}
+}
+
+func TestCrud(t *testing.T) {
+	ctx := context.Background()
+	row := &item{ID: 100, Name: "crud", CreatedAt: created}
+	if err := items.Create(ctx, row); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	row.Name = "updated"
+	if err := items.Update(ctx, row); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, err := items.Get(ctx, 100)
+	if err != nil || got.Name != "updated" {
+		t.Fatalf("Get() = %+v, %v, want the updated row", got, err)
+	}
+
+	// the same values again still find the row
+	if err := items.Update(ctx, row); err != nil {
+		t.Fatalf("Update() of unchanged values error = %v", err)
+	}
+
+	if err := items.Delete(ctx, 100); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := items.Get(ctx, 100); !errors.Is(err, baseerr.ErrNotFound) {
+		t.Errorf("Get() error = %v after Delete, want ErrNotFound", err)
+	}
+	if err := items.Delete(ctx, 100); !errors.Is(err, baseerr.ErrNotFound) {
+		t.Errorf("Delete() error = %v of a missing row, want ErrNotFound", err)
+	}
+}
+
+func TestUpdateNeverInserts(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		name string
+		row  *item
+	}{
+		{name: "missing row", row: &item{ID: 200, Name: "missing", CreatedAt: created}},
+		{name: "zero primary key", row: &item{Name: "zero", CreatedAt: created}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := items.Update(ctx, tt.row); !errors.Is(err, baseerr.ErrNotFound) {
+				t.Errorf("Update() error = %v, want ErrNotFound", err)
+			}
+
+			var count int64
+			database.Get().Model(&item{}).Where("name = ?", tt.row.Name).Count(&count)
+			if count != 0 {
+				t.Errorf("Update() inserted %d rows", count)
+			}
+		})
+	}
+}
+
+func TestDbError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want *baseerr.Error
+	}{
+		{name: "not found", err: gorm.ErrRecordNotFound, want: baseerr.ErrNotFound},
+		{name: "wrapped not found", err: fmt.Errorf("take: %w", gorm.ErrRecordNotFound), want: baseerr.ErrNotFound},
+		{name: "not connected", err: baseerr.ErrServiceUnavailable, want: baseerr.ErrServiceUnavailable},
+		{name: "driver error", err: errors.New("database is locked"), want: baseerr.ErrDatabase},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := dbError(tt.err); !errors.Is(err, tt.want) {
+				t.Errorf("dbError() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+	if dbError(nil) != nil {
+		t.Error("dbError(nil) is not nil")
+	}
+}
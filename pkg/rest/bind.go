@@ -0,0 +1,153 @@
+package rest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/go-playground/validator/v10"
+	"io"
+	"reflect"
+	"strings"
+	"top-ping/pkg/baseerr"
+)
+
+func init() {
+	// registered before any request is bound, the validator caches struct fields with their names
+	registerTagName()
+}
+
+// BindJSON bind json body and validate it, failures are returned as ErrBind or ErrValidation
+func BindJSON(c *gin.Context, obj interface{}) error {
+	return bind(obj, func() error { return c.ShouldBindJSON(obj) })
+}
+
+// BindQuery bind query string and validate it
+func BindQuery(c *gin.Context, obj interface{}) error {
+	return bind(obj, func() error { return c.ShouldBindQuery(obj) })
+}
+
+// BindUri bind path params and validate it
+func BindUri(c *gin.Context, obj interface{}) error {
+	return bind(obj, func() error { return c.ShouldBindUri(obj) })
+}
+
+// Bind bind request by method and content type and validate it
+func Bind(c *gin.Context, obj interface{}) error {
+	return bind(obj, func() error { return c.ShouldBind(obj) })
+}
+
+func bind(obj interface{}, shouldBind func() error) error {
+	err := shouldBind()
+	if err == nil {
+		return nil
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		details := make([]interface{}, 0, len(validationErrs))
+		for _, fe := range validationErrs {
+			details = append(details, toFieldError(fe))
+		}
+		return baseerr.ErrValidation.WithDetails(details...)
+	}
+
+	return baseerr.ErrBind.WithDetails(bindFieldError(err))
+}
+
+// registerTagName report fields by the json/form/uri name that clients send instead of the go name
+func registerTagName() {
+	v, ok := binding.Validator.Engine().(*validator.Validate)
+	if !ok {
+		return
+	}
+
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		for _, tag := range []string{"json", "form", "uri"} {
+			name := strings.SplitN(field.Tag.Get(tag), ",", 2)[0]
+			if name == "-" {
+				return ""
+			}
+			if name != "" {
+				return name
+			}
+		}
+		return field.Name
+	})
+}
+
+func toFieldError(fe validator.FieldError) baseerr.FieldError {
+	field := fe.Namespace()
+	if idx := strings.Index(field, "."); idx >= 0 {
+		field = field[idx+1:]
+	}
+
+	return baseerr.FieldError{
+		Field:   field,
+		Rule:    fe.Tag(),
+		Message: validationMessage(fe),
+	}
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "min":
+		return fmt.Sprintf("must be at least %s", fe.Param())
+	case "max":
+		return fmt.Sprintf("must be at most %s", fe.Param())
+	case "len":
+		return fmt.Sprintf("must have length %s", fe.Param())
+	case "gt", "gte", "lt", "lte":
+		return fmt.Sprintf("must be %s %s", fe.Tag(), fe.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of [%s]", fe.Param())
+	case "email", "url", "ip", "ipv4", "ipv6", "hostname", "uuid":
+		return fmt.Sprintf("must be a valid %s", fe.Tag())
+	}
+
+	if fe.Param() != "" {
+		return fmt.Sprintf("failed on rule %s=%s", fe.Tag(), fe.Param())
+	}
+	return fmt.Sprintf("failed on rule %s", fe.Tag())
+}
+
+func bindFieldError(err error) baseerr.FieldError {
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) {
+		return baseerr.FieldError{
+			Field:   typeErr.Field,
+			Rule:    "type",
+			Message: fmt.Sprintf("must be %s, got %s", typeErr.Type.String(), typeErr.Value),
+		}
+	}
+
+	var syntaxErr *json.SyntaxError
+	if errors.As(err, &syntaxErr) {
+		return baseerr.FieldError{
+			Rule:    "syntax",
+			Message: fmt.Sprintf("malformed json at offset %d", syntaxErr.Offset),
+		}
+	}
+
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return baseerr.FieldError{
+			Rule:    "syntax",
+			Message: "malformed json, unexpected end of body",
+		}
+	}
+
+	if errors.Is(err, io.EOF) {
+		return baseerr.FieldError{
+			Rule:    "required",
+			Message: "request body is empty",
+		}
+	}
+
+	return baseerr.FieldError{
+		Rule:    "bind",
+		Message: err.Error(),
+	}
+}
@@ -0,0 +1,147 @@
+package rest
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"top-ping/pkg/baseerr"
+)
+
+type contact struct {
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+type createUser struct {
+	UserName string  `json:"user_name" binding:"required"`
+	Age      int     `json:"age" binding:"min=18"`
+	Role     string  `json:"role" binding:"omitempty,oneof=admin member"`
+	Contact  contact `json:"contact"`
+	Internal string  `json:"-"`
+	NoTag    string  `binding:"max=3"`
+}
+
+type listUsers struct {
+	PageSize int    `form:"page_size" binding:"max=100"`
+	Sort     string `form:"sort,omitempty" binding:"omitempty,oneof=name age"`
+}
+
+func TestBind(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.POST("/users", func(c *gin.Context) {
+		var req createUser
+		if err := BindJSON(c, &req); err != nil {
+			R.Error(c, err)
+			return
+		}
+		R.Success(c, nil)
+	})
+	r.GET("/users", func(c *gin.Context) {
+		var req listUsers
+		if err := BindQuery(c, &req); err != nil {
+			R.Error(c, err)
+			return
+		}
+		R.Success(c, nil)
+	})
+
+	tests := []struct {
+		name    string
+		method  string
+		target  string
+		body    string
+		status  int
+		code    int
+		details []baseerr.FieldError
+	}{
+		{
+			name:   "valid",
+			method: http.MethodPost, target: "/users", body: `{"user_name":"jane","age":18}`,
+			status: http.StatusOK, code: baseerr.Success.Code(),
+		},
+		{
+			name:   "json names of invalid fields",
+			method: http.MethodPost, target: "/users", body: `{"age":17,"role":"root","contact":{"email":"x"},"NoTag":"long"}`,
+			status: baseerr.ErrValidation.StatusCode(), code: baseerr.ErrValidation.Code(),
+			details: []baseerr.FieldError{
+				{Field: "user_name", Rule: "required", Message: "is required"},
+				{Field: "age", Rule: "min", Message: "must be at least 18"},
+				{Field: "role", Rule: "oneof", Message: "must be one of [admin member]"},
+				{Field: "contact.email", Rule: "email", Message: "must be a valid email"},
+				{Field: "NoTag", Rule: "max", Message: "must be at most 3"},
+			},
+		},
+		{
+			name:   "form names of invalid fields",
+			method: http.MethodGet, target: "/users?page_size=101&sort=email",
+			status: baseerr.ErrValidation.StatusCode(), code: baseerr.ErrValidation.Code(),
+			details: []baseerr.FieldError{
+				{Field: "page_size", Rule: "max", Message: "must be at most 100"},
+				{Field: "sort", Rule: "oneof", Message: "must be one of [name age]"},
+			},
+		},
+		{
+			name:   "wrong type",
+			method: http.MethodPost, target: "/users", body: `{"user_name":"jane","age":"x"}`,
+			status: baseerr.ErrBind.StatusCode(), code: baseerr.ErrBind.Code(),
+			details: []baseerr.FieldError{{Field: "age", Rule: "type", Message: "must be int, got string"}},
+		},
+		{
+			name:   "malformed json",
+			method: http.MethodPost, target: "/users", body: `{"user_name" "jane"}`,
+			status: baseerr.ErrBind.StatusCode(), code: baseerr.ErrBind.Code(),
+			details: []baseerr.FieldError{{Rule: "syntax", Message: "malformed json at offset 14"}},
+		},
+		{
+			name:   "truncated json",
+			method: http.MethodPost, target: "/users", body: `{"user_name":`,
+			status: baseerr.ErrBind.StatusCode(), code: baseerr.ErrBind.Code(),
+			details: []baseerr.FieldError{{Rule: "syntax", Message: "malformed json, unexpected end of body"}},
+		},
+		{
+			name:   "empty body",
+			method: http.MethodPost, target: "/users",
+			status: baseerr.ErrBind.StatusCode(), code: baseerr.ErrBind.Code(),
+			details: []baseerr.FieldError{{Rule: "required", Message: "request body is empty"}},
+		},
+		{
+			name:   "query not a number",
+			method: http.MethodGet, target: "/users?page_size=x",
+			status: baseerr.ErrBind.StatusCode(), code: baseerr.ErrBind.Code(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			var res struct {
+				Code    int                  `json:"code"`
+				Details []baseerr.FieldError `json:"details"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &res); err != nil {
+				t.Fatalf("response %s: %v", w.Body, err)
+			}
+			if w.Code != tt.status || res.Code != tt.code {
+				t.Errorf("status %d, code %d, want %d, %d", w.Code, res.Code, tt.status, tt.code)
+			}
+			if tt.details == nil {
+				// only the shape of errors not worded by us
+				if tt.code != baseerr.Success.Code() && len(res.Details) != 1 {
+					t.Errorf("details %+v, want one", res.Details)
+				}
+				return
+			}
+			if !reflect.DeepEqual(res.Details, tt.details) {
+				t.Errorf("details %+v, want %+v", res.Details, tt.details)
+			}
+		})
+	}
+}
@@ -2,20 +2,23 @@ package rest
 
 import (
 	"context"
+	"errors"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 	"net/http"
 	"top-ping/pkg/baseerr"
+	"top-ping/pkg/logger"
 	"top-ping/pkg/utils"
 )
 
 var R = NewResponse()
 
 type Response struct {
-	Code    int         `json:"code"`
-	Message string      `json:"message"`
-	Data    interface{} `json:"data"`
-	Details []string    `json:"details"`
-	TraceID string      `json:"traceId"`
+	Code    int           `json:"code"`
+	Message string        `json:"message"`
+	Data    interface{}   `json:"data"`
+	Details []interface{} `json:"details"`
+	TraceID string        `json:"traceId"`
 }
 
 func NewResponse() *Response {
@@ -29,41 +32,67 @@ func (r *Response) Success(c *gin.Context, data interface{}) {
 
 	c.JSON(http.StatusOK, &Response{
 		Code:    baseerr.Success.Code(),
-		Message: baseerr.Success.Msg(),
+		Message: baseerr.Success.MsgIn(getLang(c)),
 		Data:    data,
-		Details: []string{},
+		Details: []interface{}{},
 		TraceID: getTraceId(c.Request.Context()),
 	})
 }
 
 func (r *Response) Error(c *gin.Context, error error) {
 	if error != nil {
-		if v, ok := error.(*baseerr.Error); ok {
-			response := &Response{
-				Code:    v.Code(),
-				Message: v.Msg(),
-				Data:    gin.H{},
-				Details: []string{},
-				TraceID: getTraceId(c.Request.Context()),
-			}
-
-			details := v.Details()
-			if len(details) > 0 {
-				response.Details = details
-			}
-			c.JSON(v.StatusCode(), response)
-			return
+		var v *baseerr.Error
+		if !errors.As(error, &v) {
+			v = baseerr.ErrInternalServer.Wrap(error)
 		}
+		logCause(c, v)
+
+		response := &Response{
+			Code:    v.Code(),
+			Message: v.MsgIn(getLang(c)),
+			Data:    gin.H{},
+			Details: []interface{}{},
+			TraceID: getTraceId(c.Request.Context()),
+		}
+
+		details := v.Details()
+		if len(details) > 0 {
+			response.Details = details
+		}
+		c.JSON(v.StatusCode(), response)
+		return
 	}
 
 	c.JSON(http.StatusOK, &Response{
 		Code:    baseerr.Success.Code(),
-		Message: baseerr.Success.Msg(),
+		Message: baseerr.Success.MsgIn(getLang(c)),
 		Data:    gin.H{},
 		TraceID: getTraceId(c.Request.Context()),
 	})
 }
 
+// logCause the cause of a wrapped error is only for us, clients never see it
+func logCause(c *gin.Context, v *baseerr.Error) {
+	if v.Cause() == nil {
+		return
+	}
+
+	fields := []zap.Field{
+		zap.Int("Code", v.Code()),
+		zap.String("Path", c.Request.URL.Path),
+		zap.Error(v.Cause()),
+	}
+	if v.StatusCode() >= http.StatusInternalServerError {
+		logger.Error(c.Request.Context(), "RequestError", fields...)
+	} else {
+		logger.Warn(c.Request.Context(), "RequestError", fields...)
+	}
+}
+
+func getLang(c *gin.Context) string {
+	return baseerr.MatchLang(c.GetHeader("Accept-Language"))
+}
+
 func getTraceId(c context.Context) string {
 	var traceID string
 
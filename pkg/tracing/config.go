@@ -0,0 +1,37 @@
+package tracing
+
+import "fmt"
+
+const OtlpExporter = "otlp"
+
+type Config struct {
+	Enabled bool `mapstructure:"enabled"`
+	// Exporter otlp, the only one, tests record spans with tracingtest
+	Exporter string `mapstructure:"exporter"`
+	// Endpoint host:port of the OTLP/HTTP collector
+	Endpoint    string  `mapstructure:"endpoint"`
+	Insecure    bool    `mapstructure:"insecure"`
+	ServiceName string  `mapstructure:"serviceName"`
+	SampleRatio float64 `mapstructure:"sampleRatio"`
+}
+
+func (c *Config) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+
+	switch c.Exporter {
+	case "", OtlpExporter:
+		if c.Endpoint == "" {
+			return fmt.Errorf("tracing.endpoint: is required by the %s exporter", OtlpExporter)
+		}
+	default:
+		return fmt.Errorf("tracing.exporter: must be %s, got %q", OtlpExporter, c.Exporter)
+	}
+
+	if c.SampleRatio < 0 || c.SampleRatio > 1 {
+		return fmt.Errorf("tracing.sampleRatio: must be between 0 and 1, got %v", c.SampleRatio)
+	}
+
+	return nil
+}
@@ -6,4 +6,7 @@ const (
 	ProdProfile = "prod"
 	TraceKey    = "TraceID"
 	TraceLen    = 10
+	UserKey     = "UserID"
+	// ApiKeyKey the api key id, set in the gin context once the key is authenticated
+	ApiKeyKey = "ApiKeyID"
 )
@@ -7,6 +7,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	tnet "github.com/toolkits/net"
+	"top-ping/pkg/clientip"
 )
 
 var (
@@ -51,37 +52,7 @@ func GetInternalIP() string {
 	return ""
 }
 
-// GetRealIP get user real ip
-func GetRealIP(ctx *gin.Context) (ip string) {
-	var header = ctx.Request.Header
-	var index int
-	if ip = header.Get("X-Forwarded-For"); ip != "" {
-		index = strings.IndexByte(ip, ',')
-		if index < 0 {
-			return ip
-		}
-		if ip = ip[:index]; ip != "" {
-			return ip
-		}
-	}
-	if ip = header.Get("X-Real-Ip"); ip != "" {
-		index = strings.IndexByte(ip, ',')
-		if index < 0 {
-			return ip
-		}
-		if ip = ip[:index]; ip != "" {
-			return ip
-		}
-	}
-	if ip = header.Get("Proxy-Forwarded-For"); ip != "" {
-		index = strings.IndexByte(ip, ',')
-		if index < 0 {
-			return ip
-		}
-		if ip = ip[:index]; ip != "" {
-			return ip
-		}
-	}
-	ip, _, _ = net.SplitHostPort(ctx.Request.RemoteAddr)
-	return ip
+// GetRealIP get user real ip, forwarding headers are only trusted from the trusted proxies
+func GetRealIP(ctx *gin.Context) string {
+	return clientip.FromRequest(ctx.Request)
 }
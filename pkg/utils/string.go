@@ -2,35 +2,14 @@ package utils
 
 import (
 	"encoding/json"
-	"math/rand"
 	"strings"
-	"time"
-	"unsafe"
-)
-
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
-var (
-	chars    = "0123456789abcdefghijklmnopqrstuvwxyz"
-	charsLen = len(chars)
-	rng      = rand.NewSource(time.Now().UnixNano())
-	mask     = int64(1<<6 - 1)
+	"top-ping/pkg/idgen"
+	"top-ping/pkg/masking"
 )
 
+// RandomString n chars of [0-9a-z] from crypto/rand, safe for concurrent use
 func RandomString(n int) string {
-	buf := make([]byte, n)
-	for idx, cache, remain := n, rng.Int63(), 10; idx > 0; {
-		if remain == 0 {
-			cache, remain = rng.Int63(), 10
-		}
-		buf[idx-1] = chars[int(cache&mask)%charsLen]
-		cache >>= 6
-		remain--
-		idx--
-	}
-	return *(*string)(unsafe.Pointer(&buf))
+	return idgen.RandomString(n)
 }
 
 // JsonToMap Convert json string to map
@@ -54,76 +33,41 @@ func MapToJson(m *map[string]interface{}) (string, error) {
 	return string(jsonByte), nil
 }
 
+// MaskJsonStr mask the json string by the masking rules of fieldNames, see masking.Rule
 func MaskJsonStr(jsonStr *string, fieldNames []string) string {
-	if !json.Valid([]byte(*jsonStr)) {
-		return *jsonStr
-	}
-
-	jm, err := JsonToMap(jsonStr)
-	if err != nil {
-		return *jsonStr
-	}
-
-	for _, key := range fieldNames {
-		MaskField(jm, key)
-	}
-
-	maskedJson, err := MapToJson(&jm)
-	if err != nil {
-		return *jsonStr
-	}
-
-	return maskedJson
+	masker, _ := masking.New(fieldNames)
+	return masker.MaskJSON(*jsonStr)
 }
 
 // MaskStruct mask struct object then return string
 func MaskStruct(src interface{}, fieldNames []string) string {
-	jsonBytes, err := json.Marshal(src)
-	if err != nil {
-		return ""
-	}
-
-	jsonStr := string(jsonBytes)
-	jm, err := JsonToMap(&jsonStr)
-	if err != nil {
-		return jsonStr
-	}
-
-	for _, key := range fieldNames {
-		MaskField(jm, key)
-	}
-
-	maskedJson, err := MapToJson(&jm)
-	if err != nil {
-		return jsonStr
-	}
-
-	return maskedJson
+	masker, _ := masking.New(fieldNames)
+	return masker.MaskStruct(src)
 }
 
+// MaskField mask the values of keys matching the rule field, nested objects and arrays included
 func MaskField(jm map[string]interface{}, field string) {
-	for k, v := range jm {
-		switch vv := v.(type) {
-		case bool, string, float64, int, []interface{}:
-			if k == field || strings.Contains(k, field) {
-				jm[k] = nil
-			}
-		case map[string]interface{}:
-			MaskField(vv, field)
-		case nil:
-		default:
-		}
-	}
+	masker, _ := masking.New([]string{field})
+	masker.Mask(jm)
 }
 
-func MaskHttpHeader(jm map[string][]string, fieldNames []string) map[string][]string {
-	for _, key := range fieldNames {
-		for k, _ := range jm {
-			if k == key || strings.Contains(k, key) {
-				jm[k][0] = "***"
+// MaskHttpHeader a copy of header with the values of fieldNames masked, names are case-insensitive.
+// header itself is left as it is, handlers still need the real values.
+func MaskHttpHeader(header map[string][]string, fieldNames []string) map[string][]string {
+	masked := make(map[string][]string, len(header))
+	for k, values := range header {
+		masked[k] = values
+		for _, key := range fieldNames {
+			if strings.EqualFold(k, key) {
+				maskedValues := make([]string, len(values))
+				for i := range maskedValues {
+					maskedValues[i] = masking.Mask
+				}
+				masked[k] = maskedValues
+				break
 			}
 		}
 	}
 
-	return jm
+	return masked
 }